@@ -46,12 +46,11 @@ define _redirect_: syntax (name mode closer) = {
 		return s
 	}
 }
-define ...: method (: args) = {
+define ...: method (path (relative ())) = {
 	cd _origin_
-	define path: args::head
-	if (eq 2: args::length) {
+	if (not: is-null relative) {
 		cd path
-		set path: args::get 1
+		set path = relative
 	}
 	while true {
 		define abs: symbol: "/"::join $PWD path
@@ -155,34 +154,19 @@ define is-list: method (l) = {
 }
 define is-text: method (t) =: or (is-string t) (is-symbol t)
 define map: syntax (: literal) e = {
-    define o: object
+    define m: _map_
     for literal: method (entry) = {
         define k: entry::head
         if (is-list k) {
             set k: e::eval k
 	}
-        define v: entry::get 1
+        define v: entry::tail
         if (eq 1: v::length) {
             set v: v::head
         }
-        o::_set_ k: e::eval v
+        m::set k: e::eval v
     }
-    public del = _del_
-    public get = _get_
-    public set = _set_
-    return o
-}
-# TODO: Replace with builtin rather than invoking bc.
-define math: method (S) e = {
-	catch ex {
-		set ex::type = "error/syntax"
-		set ex::message = "Malformed expression: ${S}"
-	}
-
-	float @(_backtick_ (block {
-		echo "scale=6"
-		write: symbol S
-	} | bc))
+    return m
 }
 define object: syntax (: body) e = {
 	e::eval: cons (quote block): body::append (quote: context)
@@ -316,17 +300,19 @@ _sys_::public _exception: method (t s m l f) e = {
 # exception <status> <message>
 # exception <type> <status> <message>
 # If not provided status defaults to status false and type to error/runtime.
-_sys_::public exception: method (message :args) e = {
-	define s: status false
+_sys_::public exception: method (first (second ()) (third ())) e = {
 	define t: symbol "error/runtime"
-	if (not: is-null args) {
-		set s: args::head
-		set args: args::tail
-	}
-	if (not: is-null args) {
-		set t = s
-		set s: args::head
-		set args: args::tail
+	define s: status false
+	define message = first
+	if (not: is-null third) {
+		set t = first
+		set s = second
+		set message = third
+	} else {
+		if (not: is-null second) {
+			set s = first
+			set message = second
+		}
 	}
 	_exception t s message {
 		public line: e::eval: get-line-number
@@ -341,7 +327,11 @@ _sys_::public get-prompt: method self (suffix) = {
 }
 _sys_::public prompt: method (suffix) = {
 	define dirs: "/"::split $PWD
-	return: ""::join (dirs::get -1) suffix
+	define dir: dirs::get -1
+	if (gt (dir::length -g) 24) {
+		set dir: ""::join (dir::slice -g 0 23) "…"
+	}
+	return: ""::join dir suffix
 }
 _sys_::public throw: method (c) = {
 	error: ": "::join c::file c::line c::type c::message
@@ -0,0 +1,77 @@
+// Released under an MIT license. See LICENSE.
+
+package cell
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"weak"
+)
+
+/*
+ * Handle definition.
+ * (A handle maps an opaque ID to a live cell without keeping it alive).
+ * (The high bits of an ID are a per-process nonce so that IDs issued by
+ * another process are never mistaken for our own).
+ */
+
+type handle struct {
+	kind  string
+	value func() Cell
+}
+
+var (
+	handles = map[uint64]handle{}
+	handlei = map[any]uint64{}
+	handlel = &sync.Mutex{}
+	handlen uint64
+	handlep = uint64(rand.Uint32()|1) << 32
+)
+
+func Handle[T any, P interface {
+	*T
+	Cell
+}](kind string, p P) string {
+	w := weak.Make((*T)(p))
+
+	handlel.Lock()
+	defer handlel.Unlock()
+
+	id, ok := handlei[w]
+	if !ok {
+		handlen++
+		id = handlep | handlen&0xffffffff
+
+		handlei[w] = id
+		handles[id] = handle{kind, func() Cell {
+			if v := w.Value(); v != nil {
+				return P(v)
+			}
+			return nil
+		}}
+
+		runtime.AddCleanup((*T)(p), func(id uint64) {
+			handlel.Lock()
+			defer handlel.Unlock()
+
+			delete(handlei, w)
+			delete(handles, id)
+		}, id)
+	}
+
+	return fmt.Sprintf("%%%s %d%%", kind, id)
+}
+
+func Lookup(kind string, id uint64) Cell {
+	handlel.Lock()
+	h, ok := handles[id]
+	handlel.Unlock()
+
+	if !ok || h.kind != kind {
+		return nil
+	}
+
+	return h.value()
+}
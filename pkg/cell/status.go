@@ -1,6 +1,6 @@
 // Released under an MIT license. See LICENSE.
 
-// +build !plan9
+//go:build !plan9
 
 package cell
 
@@ -38,6 +38,10 @@ func (b *Boolean) Status() int64 {
 	return 1
 }
 
+func (b *BigInteger) Status() int64 {
+	return b.Int()
+}
+
 func (f *Float) Status() int64 {
 	return f.Int()
 }
@@ -1,13 +1,12 @@
 // Released under an MIT license. See LICENSE.
 
-// +build plan9
+//go:build plan9
 
 package cell
 
 import (
-        "fmt"
-        "math/big"
-        "strconv"
+	"math/big"
+	"strconv"
 )
 
 type Atom interface {
@@ -22,8 +21,8 @@ type Atom interface {
 var (
 	ExitFailure *Status
 	ExitSuccess *Status
-	failure = "failure"
-	success = ""
+	failure     = "failure"
+	success     = ""
 )
 
 func init() {
@@ -38,6 +37,13 @@ func (b *Boolean) Status() string {
 	return failure
 }
 
+func (b *BigInteger) Status() string {
+	if !b.Bool() {
+		return success
+	}
+	return failure
+}
+
 func (f *Float) Status() string {
 	if !f.Bool() {
 		return success
@@ -96,11 +102,7 @@ func (s *Status) String() string {
 }
 
 func (s *Status) Float() float64 {
-	f, err := strconv.ParseFloat(string(*s), 64)
-	if err != nil {
-		panic(err)
-	}
-	return f
+	return parseFloat(string(*s))
 }
 
 func (s *Status) Int() int64 {
@@ -112,11 +114,7 @@ func (s *Status) Int() int64 {
 }
 
 func (s *Status) Rat() *big.Rat {
-	r := new(big.Rat)
-	if _, err := fmt.Sscan(string(*s), r); err != nil {
-		panic(err)
-	}
-	return r
+	return parseRat(string(*s))
 }
 
 func (s *Status) Status() string {
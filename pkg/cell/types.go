@@ -7,6 +7,7 @@ import (
 	"github.com/michaelmacinnis/adapted"
 	"math/big"
 	"strconv"
+	"strings"
 	"sync"
 )
 
@@ -82,6 +83,62 @@ func CacheSymbols(symbols ...string) {
 	}
 }
 
+func bigint(c Cell) (*big.Int, bool) {
+	switch t := c.(type) {
+	case *BigInteger:
+		return t.v, true
+	case *Integer:
+		return big.NewInt(int64(*t)), true
+	case *String, *Symbol:
+		return new(big.Int).SetString(Raw(t), 10)
+	}
+	return nil, false
+}
+
+// Map keys and set members are distinguished by type as well as value.
+// Numbers of any representation are compared by value.
+func key(c Cell) string {
+	switch t := c.(type) {
+	case *Pair:
+		l := []string{}
+		for ; c != Null && IsPair(c); c = Cdr(c) {
+			l = append(l, key(Car(c)))
+		}
+		if c != Null {
+			l = append(l, ".", key(c))
+		}
+		return "(" + strings.Join(l, " ") + ")"
+	case *String, *Symbol:
+		return fmt.Sprintf("%T:%s", c, Raw(t))
+	case Number:
+		return "number:" + t.Rat().RatString()
+	case Atom:
+		return fmt.Sprintf("%T:%s", c, Raw(t))
+	}
+
+	return fmt.Sprintf("%T", c)
+}
+
+func parseFloat(s string) float64 {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			panic(err)
+		}
+		f, _ = r.Float64()
+	}
+	return f
+}
+
+func parseRat(s string) *big.Rat {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		panic("'" + s + "' is not a number")
+	}
+	return r
+}
+
 func ratmod(x, y *big.Rat) *big.Rat {
 	if x.IsInt() && y.IsInt() {
 		return new(big.Rat).SetInt(new(big.Int).Mod(x.Num(), y.Num()))
@@ -90,6 +147,94 @@ func ratmod(x, y *big.Rat) *big.Rat {
 	panic("operation not permitted")
 }
 
+/* BigInteger cell definition. */
+
+type BigInteger struct {
+	v *big.Int
+}
+
+func NewBigInteger(v *big.Int) Number {
+	if v.IsInt64() {
+		return NewInteger(v.Int64())
+	}
+
+	return &BigInteger{v}
+}
+
+func (b *BigInteger) Bool() bool {
+	return b.v.Sign() != 0
+}
+
+func (b *BigInteger) Equal(c Cell) bool {
+	if a, ok := c.(Atom); ok {
+		return b.Rat().Cmp(a.Rat()) == 0
+	}
+	return false
+}
+
+func (b *BigInteger) String() string {
+	return b.v.String()
+}
+
+func (b *BigInteger) Float() float64 {
+	f, _ := new(big.Float).SetInt(b.v).Float64()
+	return f
+}
+
+func (b *BigInteger) Int() int64 {
+	return b.v.Int64()
+}
+
+func (b *BigInteger) Rat() *big.Rat {
+	return new(big.Rat).SetInt(b.v)
+}
+
+func (b *BigInteger) Greater(c Cell) bool {
+	return b.Rat().Cmp(c.(Atom).Rat()) > 0
+}
+
+func (b *BigInteger) Less(c Cell) bool {
+	return b.Rat().Cmp(c.(Atom).Rat()) < 0
+}
+
+func (b *BigInteger) Add(c Cell) Number {
+	if n, ok := bigint(c); ok {
+		return NewBigInteger(new(big.Int).Add(b.v, n))
+	}
+	return NewRational(new(big.Rat).Add(b.Rat(), c.(Atom).Rat()))
+}
+
+func (b *BigInteger) Divide(c Cell) Number {
+	return NewRational(new(big.Rat).Quo(b.Rat(), c.(Atom).Rat()))
+}
+
+func (b *BigInteger) Modulo(c Cell) Number {
+	if n, ok := bigint(c); ok {
+		return NewBigInteger(new(big.Int).Mod(b.v, n))
+	}
+	return NewRational(ratmod(b.Rat(), c.(Atom).Rat()))
+}
+
+func (b *BigInteger) Multiply(c Cell) Number {
+	if n, ok := bigint(c); ok {
+		return NewBigInteger(new(big.Int).Mul(b.v, n))
+	}
+	return NewRational(new(big.Rat).Mul(b.Rat(), c.(Atom).Rat()))
+}
+
+func (b *BigInteger) Subtract(c Cell) Number {
+	if n, ok := bigint(c); ok {
+		return NewBigInteger(new(big.Int).Sub(b.v, n))
+	}
+	return NewRational(new(big.Rat).Sub(b.Rat(), c.(Atom).Rat()))
+}
+
+/* BigInteger-specific functions. */
+
+func (b *BigInteger) Big() *big.Int {
+	return b.v
+}
+
 /* Boolean cell definition. */
 
 type Boolean bool
@@ -159,7 +304,7 @@ func NewConstant(v Cell) *Constant {
 }
 
 func (ct *Constant) String() string {
-	return fmt.Sprintf("%%ct %p%%", ct)
+	return Handle("constant", ct)
 }
 
 func (ct *Constant) Set(c Cell) {
@@ -244,7 +389,7 @@ type Integer int64
 
 func IsInteger(c Cell) bool {
 	switch c.(type) {
-	case *Integer:
+	case *BigInteger, *Integer:
 		return true
 	}
 	return false
@@ -274,6 +419,23 @@ func NewInteger(v int64) *Integer {
 	return &i
 }
 
+func ToInteger(a Atom) Number {
+	switch t := a.(type) {
+	case *BigInteger, *Integer:
+		return t.(Number)
+	case *Float:
+		v, _ := big.NewFloat(float64(*t)).Int(nil)
+		return NewBigInteger(v)
+	case Rational:
+		return NewBigInteger(new(big.Int).Div(t.v.Num(), t.v.Denom()))
+	case *String, *Symbol:
+		if v, ok := new(big.Int).SetString(Raw(t), 0); ok {
+			return NewBigInteger(v)
+		}
+	}
+	return NewInteger(a.Int())
+}
+
 func (i *Integer) Bool() bool {
 	return *i != 0
 }
@@ -310,6 +472,9 @@ func (i *Integer) Less(c Cell) bool {
 }
 
 func (i *Integer) Add(c Cell) Number {
+	if n, ok := bigint(c); ok {
+		return NewBigInteger(new(big.Int).Add(i.Big(), n))
+	}
 	return NewRational(new(big.Rat).Add(i.Rat(), c.(Atom).Rat()))
 }
 
@@ -318,17 +483,212 @@ func (i *Integer) Divide(c Cell) Number {
 }
 
 func (i *Integer) Modulo(c Cell) Number {
+	if n, ok := bigint(c); ok {
+		return NewBigInteger(new(big.Int).Mod(i.Big(), n))
+	}
 	return NewRational(ratmod(i.Rat(), c.(Atom).Rat()))
 }
 
 func (i *Integer) Multiply(c Cell) Number {
+	if n, ok := bigint(c); ok {
+		return NewBigInteger(new(big.Int).Mul(i.Big(), n))
+	}
 	return NewRational(new(big.Rat).Mul(i.Rat(), c.(Atom).Rat()))
 }
 
 func (i *Integer) Subtract(c Cell) Number {
+	if n, ok := bigint(c); ok {
+		return NewBigInteger(new(big.Int).Sub(i.Big(), n))
+	}
 	return NewRational(new(big.Rat).Sub(i.Rat(), c.(Atom).Rat()))
 }
 
+/* Integer-specific functions. */
+
+func (i *Integer) Big() *big.Int {
+	return big.NewInt(int64(*i))
+}
+
+/* Map cell definition. */
+
+type Map struct {
+	buckets map[string][]*entry
+	entries []*entry
+	size    int
+}
+
+type entry struct {
+	k Cell
+	v Cell
+}
+
+func IsMap(c Cell) bool {
+	switch c.(type) {
+	case *Map:
+		return true
+	}
+	return false
+}
+
+func NewMap() *Map {
+	return &Map{buckets: map[string][]*entry{}}
+}
+
+func (m *Map) Bool() bool {
+	return m.size != 0
+}
+
+func (m *Map) Equal(c Cell) bool {
+	n, ok := c.(*Map)
+	if !ok || n.size != m.size {
+		return false
+	}
+
+	for _, e := range m.entries {
+		if e.k == nil {
+			continue
+		}
+
+		f := n.find(e.k)
+		if f == nil || !e.v.Equal(f.v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m *Map) String() string {
+	l := []string{}
+	for _, e := range m.entries {
+		if IsCons(e.v) && e.v != Null {
+			l = append(l, e.k.String()+": ("+e.v.String()+")")
+		} else if e.k != nil {
+			l = append(l, e.k.String()+": "+e.v.String())
+		}
+	}
+
+	return "{" + strings.Join(l, ", ") + "}"
+}
+
+/* Map-specific functions. */
+
+func (m *Map) Del(k Cell) bool {
+	e := m.find(k)
+	if e == nil {
+		return false
+	}
+
+	d := key(k)
+	b := m.buckets[d]
+	for i := range b {
+		if b[i] == e {
+			b = append(b[:i], b[i+1:]...)
+			break
+		}
+	}
+	if len(b) == 0 {
+		delete(m.buckets, d)
+	} else {
+		m.buckets[d] = b
+	}
+
+	e.k, e.v = nil, nil
+	m.size--
+
+	if m.size < len(m.entries)/2 {
+		l := make([]*entry, 0, m.size)
+		for _, e := range m.entries {
+			if e.k != nil {
+				l = append(l, e)
+			}
+		}
+		m.entries = l
+	}
+
+	return true
+}
+
+func (m *Map) Entries() Cell {
+	return m.collect(func(e *entry) Cell {
+		return List(e.k, e.v)
+	})
+}
+
+func (m *Map) Get(k Cell, dflt Cell) Cell {
+	e := m.find(k)
+	if e == nil {
+		if dflt == nil {
+			panic("'" + k.String() + "' undefined")
+		}
+		return dflt
+	}
+
+	return e.v
+}
+
+func (m *Map) Has(k Cell) bool {
+	return m.find(k) != nil
+}
+
+func (m *Map) Keys() Cell {
+	return m.collect(func(e *entry) Cell {
+		return e.k
+	})
+}
+
+func (m *Map) Length() int64 {
+	return int64(m.size)
+}
+
+func (m *Map) Merge(n *Map) {
+	for _, e := range n.entries {
+		if e.k != nil {
+			m.Set(e.k, e.v)
+		}
+	}
+}
+
+func (m *Map) Set(k, v Cell) {
+	if e := m.find(k); e != nil {
+		e.v = v
+		return
+	}
+
+	e := &entry{k, v}
+	d := key(k)
+	m.buckets[d] = append(m.buckets[d], e)
+	m.entries = append(m.entries, e)
+	m.size++
+}
+
+func (m *Map) Values() Cell {
+	return m.collect(func(e *entry) Cell {
+		return e.v
+	})
+}
+
+func (m *Map) collect(f func(e *entry) Cell) Cell {
+	l := Null
+	for i := len(m.entries) - 1; i >= 0; i-- {
+		if m.entries[i].k != nil {
+			l = Cons(f(m.entries[i]), l)
+		}
+	}
+
+	return l
+}
+
+func (m *Map) find(k Cell) *entry {
+	for _, e := range m.buckets[key(k)] {
+		if e.k.Equal(k) || k.Equal(e.k) {
+			return e
+		}
+	}
+
+	return nil
+}
+
 /* Pair cell definition. */
 
 type Pair struct {
@@ -484,6 +844,182 @@ func (r Rational) Subtract(c Cell) Number {
 	return NewRational(new(big.Rat).Sub(r.v, c.(Atom).Rat()))
 }
 
+/* Set cell definition. */
+
+type Set struct {
+	buckets map[string][]*member
+	members []*member
+	size    int
+}
+
+type member struct {
+	c Cell
+}
+
+func IsSet(c Cell) bool {
+	switch c.(type) {
+	case *Set:
+		return true
+	}
+	return false
+}
+
+func NewSet(l ...Cell) *Set {
+	s := &Set{buckets: map[string][]*member{}}
+	for _, c := range l {
+		s.Add(c)
+	}
+
+	return s
+}
+
+func (s *Set) Bool() bool {
+	return s.size != 0
+}
+
+func (s *Set) Equal(c Cell) bool {
+	o, ok := c.(*Set)
+	return ok && s.size == o.size && s.Subset(o)
+}
+
+func (s *Set) String() string {
+	l := []string{}
+	for _, m := range s.members {
+		if IsCons(m.c) && m.c != Null {
+			l = append(l, "("+m.c.String()+")")
+		} else if m.c != nil {
+			l = append(l, m.c.String())
+		}
+	}
+
+	return "{" + strings.Join(l, " ") + "}"
+}
+
+/* Set-specific functions. */
+
+func (s *Set) Add(c Cell) bool {
+	if s.find(c) != nil {
+		return false
+	}
+
+	m := &member{c}
+	k := key(c)
+	s.buckets[k] = append(s.buckets[k], m)
+	s.members = append(s.members, m)
+	s.size++
+
+	return true
+}
+
+func (s *Set) Difference(o *Set) *Set {
+	return s.filter(func(c Cell) bool {
+		return !o.Has(c)
+	})
+}
+
+func (s *Set) Has(c Cell) bool {
+	return s.find(c) != nil
+}
+
+func (s *Set) Intersection(o *Set) *Set {
+	return s.filter(o.Has)
+}
+
+func (s *Set) Length() int64 {
+	return int64(s.size)
+}
+
+func (s *Set) List() Cell {
+	l := Null
+	for i := len(s.members) - 1; i >= 0; i-- {
+		if s.members[i].c != nil {
+			l = Cons(s.members[i].c, l)
+		}
+	}
+
+	return l
+}
+
+func (s *Set) Remove(c Cell) bool {
+	m := s.find(c)
+	if m == nil {
+		return false
+	}
+
+	k := key(c)
+	b := s.buckets[k]
+	for i := range b {
+		if b[i] == m {
+			b = append(b[:i], b[i+1:]...)
+			break
+		}
+	}
+	if len(b) == 0 {
+		delete(s.buckets, k)
+	} else {
+		s.buckets[k] = b
+	}
+
+	m.c = nil
+	s.size--
+
+	if s.size < len(s.members)/2 {
+		l := make([]*member, 0, s.size)
+		for _, m := range s.members {
+			if m.c != nil {
+				l = append(l, m)
+			}
+		}
+		s.members = l
+	}
+
+	return true
+}
+
+func (s *Set) Subset(o *Set) bool {
+	for _, m := range s.members {
+		if m.c != nil && !o.Has(m.c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *Set) Union(o *Set) *Set {
+	u := s.filter(func(c Cell) bool {
+		return true
+	})
+	for _, m := range o.members {
+		if m.c != nil {
+			u.Add(m.c)
+		}
+	}
+
+	return u
+}
+
+func (s *Set) filter(f func(c Cell) bool) *Set {
+	r := NewSet()
+	for _, m := range s.members {
+		if m.c != nil && f(m.c) {
+			r.Add(m.c)
+		}
+	}
+
+	return r
+}
+
+func (s *Set) find(c Cell) *member {
+	for _, m := range s.buckets[key(c)] {
+		if m.c.Equal(c) || c.Equal(m.c) {
+			return m
+		}
+	}
+
+	return nil
+}
+
 /* String cell definition. */
 
 type String struct {
@@ -510,7 +1046,7 @@ func (s *String) Bool() bool {
 
 func (s *String) Equal(c Cell) bool {
 	if a, ok := c.(Atom); ok {
-		return string(s.v) == a.String()
+		return string(s.v) == Raw(a)
 	}
 	return false
 }
@@ -520,11 +1056,7 @@ func (s *String) String() string {
 }
 
 func (s *String) Float() (f float64) {
-	var err error
-	if f, err = strconv.ParseFloat(string(s.v), 64); err != nil {
-		panic(err)
-	}
-	return f
+	return parseFloat(string(s.v))
 }
 
 func (s *String) Int() (i int64) {
@@ -536,11 +1068,7 @@ func (s *String) Int() (i int64) {
 }
 
 func (s *String) Rat() *big.Rat {
-	r := new(big.Rat)
-	if _, err := fmt.Sscan(string(s.v), r); err != nil {
-		panic(err)
-	}
-	return r
+	return parseRat(string(s.v))
 }
 
 /* String-specific functions. */
@@ -602,11 +1130,7 @@ func (s *Symbol) String() string {
 }
 
 func (s *Symbol) Float() (f float64) {
-	var err error
-	if f, err = strconv.ParseFloat(string(*s), 64); err != nil {
-		panic(err)
-	}
-	return f
+	return parseFloat(string(*s))
 }
 
 func (s *Symbol) Int() (i int64) {
@@ -618,11 +1142,7 @@ func (s *Symbol) Int() (i int64) {
 }
 
 func (s *Symbol) Rat() *big.Rat {
-	r := new(big.Rat)
-	if _, err := fmt.Sscan(string(*s), r); err != nil {
-		panic(err)
-	}
-	return r
+	return parseRat(string(*s))
 }
 
 func (s *Symbol) Greater(c Cell) bool {
@@ -656,9 +1176,8 @@ func (s *Symbol) Subtract(c Cell) Number {
 /* Symbol-specific functions. */
 
 func (s *Symbol) isNumeric() bool {
-	r := new(big.Rat)
-	_, err := fmt.Sscan(string(*s), r)
-	return err == nil
+	_, ok := new(big.Rat).SetString(string(*s))
+	return ok
 }
 
 /* Variable cell definition. */
@@ -680,7 +1199,7 @@ func (vr *Variable) Equal(c Cell) bool {
 }
 
 func (vr *Variable) String() string {
-	return fmt.Sprintf("%%variable %p%%", vr)
+	return Handle("variable", vr)
 }
 
 /* Variable-specific functions */
@@ -696,3 +1215,143 @@ func (vr *Variable) Get() Cell {
 func (vr *Variable) Set(c Cell) {
 	vr.v = c
 }
+
+/* Vector cell definition. */
+
+type Vector struct {
+	v []Cell
+}
+
+func IsVector(c Cell) bool {
+	switch c.(type) {
+	case *Vector:
+		return true
+	}
+	return false
+}
+
+func NewVector(l ...Cell) *Vector {
+	return &Vector{append([]Cell{}, l...)}
+}
+
+func (v *Vector) Bool() bool {
+	return len(v.v) != 0
+}
+
+func (v *Vector) Equal(c Cell) bool {
+	u, ok := c.(*Vector)
+	if !ok || len(u.v) != len(v.v) {
+		return false
+	}
+
+	for i := range v.v {
+		if !v.v[i].Equal(u.v[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (v *Vector) String() string {
+	l := make([]string, len(v.v))
+	for i, c := range v.v {
+		if IsCons(c) && c != Null {
+			l[i] = "(" + c.String() + ")"
+		} else {
+			l[i] = c.String()
+		}
+	}
+
+	return "[" + strings.Join(l, " ") + "]"
+}
+
+/* Vector-specific functions */
+
+func (v *Vector) Get(index int64, dflt Cell) Cell {
+	i, msg := v.index(index)
+	if msg != "" {
+		if dflt == nil {
+			panic(msg)
+		}
+		return dflt
+	}
+
+	return v.v[i]
+}
+
+func (v *Vector) Length() int64 {
+	return int64(len(v.v))
+}
+
+func (v *Vector) List() Cell {
+	return List(v.v...)
+}
+
+func (v *Vector) Pop() Cell {
+	if len(v.v) == 0 {
+		panic("pop from empty vector")
+	}
+
+	c := v.v[len(v.v)-1]
+	v.v = v.v[:len(v.v)-1]
+
+	return c
+}
+
+func (v *Vector) Push(l ...Cell) {
+	v.v = append(v.v, l...)
+}
+
+func (v *Vector) Set(index int64, c Cell) {
+	i, msg := v.index(index)
+	if msg != "" {
+		panic(msg)
+	}
+
+	v.v[i] = c
+}
+
+func (v *Vector) Slice(start, end int64) *Vector {
+	length := v.Length()
+
+	if start < 0 {
+		start = length + start
+	}
+
+	if start < 0 {
+		panic("slice starts before first element")
+	} else if start > length {
+		panic("slice starts after last element")
+	}
+
+	if end <= 0 {
+		end = length + end
+	}
+
+	if end < 0 {
+		panic("slice ends before first element")
+	} else if end > length {
+		end = length
+	}
+
+	if end < start {
+		panic("end of slice before start")
+	}
+
+	return NewVector(v.v[start:end]...)
+}
+
+func (v *Vector) index(i int64) (int64, string) {
+	if i < 0 {
+		i = v.Length() + i
+	}
+
+	if i < 0 {
+		return i, "index before first element"
+	} else if i >= v.Length() {
+		return i, "index after last element"
+	}
+
+	return i, ""
+}
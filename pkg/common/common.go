@@ -12,6 +12,7 @@ const (
 	ErrNotExecutable = "oh: 126: error/runtime: "
 	ErrNotFound      = "oh: 127: error/runtime: "
 	ErrSyntax        = "oh: 1: error/syntax: "
+	ErrTimeout       = "oh: 124: error/timeout: "
 )
 
 var CtrlCPressed = errors.New("ctrl-c pressed")
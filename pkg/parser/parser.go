@@ -3,6 +3,7 @@
 package parser
 
 import (
+	"fmt"
 	. "github.com/michaelmacinnis/oh/pkg/cell"
 	"github.com/michaelmacinnis/oh/pkg/common"
 	"github.com/michaelmacinnis/oh/pkg/system"
@@ -12,7 +13,7 @@ import (
 )
 
 type parser struct {
-	deref func(string, uintptr) Cell
+	lookup func(string, uintptr) Cell
 }
 
 type scanner struct {
@@ -21,7 +22,7 @@ type scanner struct {
 	f        *os.File
 	filename string
 	input    common.ReadStringer
-	process  func(Cell, string, int, string) (Cell, bool)
+	evaluate func(Cell, string, int, string) (Cell, bool)
 
 	line []rune
 
@@ -33,6 +34,7 @@ type scanner struct {
 	token    rune
 
 	finished bool
+	invalid  bool
 }
 
 const (
@@ -330,8 +332,28 @@ func (s *scanner) Error(msg string) {
 	s.error(s.filename, s.lineno, msg)
 }
 
-func New(deref func(string, uintptr) Cell) *parser {
-	return &parser{deref}
+func (s *scanner) deref(name string, id uintptr) Cell {
+	c := s.lookup(name, id)
+	if c == nil {
+		s.Error(fmt.Sprintf("unknown or expired handle %%%s %d%%", name, id))
+		s.invalid = true
+		return Null
+	}
+
+	return c
+}
+
+func (s *scanner) process(c Cell, f string, l int, u string) (Cell, bool) {
+	if s.invalid {
+		s.invalid = false
+		return nil, true
+	}
+
+	return s.evaluate(c, f, l, u)
+}
+
+func New(lookup func(string, uintptr) Cell) *parser {
+	return &parser{lookup}
 }
 
 func (p *parser) Parse(
@@ -347,7 +369,7 @@ func (p *parser) Parse(
 	s.f = f
 	s.filename = filename
 	s.input = input
-	s.process = process
+	s.evaluate = process
 
 	rval := 1
 	for rval > 0 {
@@ -358,6 +380,7 @@ func (p *parser) Parse(
 		s.token = 0
 
 		s.finished = false
+		s.invalid = false
 
 		s.state = ssStart
 
@@ -1,6 +1,6 @@
 // Released under an MIT license. See LICENSE.
 
-// +build !linux,!darwin,!dragonfly,!freebsd,!openbsd,!netbsd,!solaris
+//go:build !linux && !darwin && !dragonfly && !freebsd && !openbsd && !netbsd && !solaris
 
 package system
 
@@ -32,6 +32,15 @@ func ResetForegroundGroup(f *os.File) bool {
 
 func SetForegroundGroup(group int) {}
 
+func SignalProcess(pid int, sig os.Signal) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+
+	return p.Signal(sig)
+}
+
 func SuspendProcess(pid int) {}
 
 func SysProcAttr(group int) *syscall.SysProcAttr {
@@ -1,6 +1,6 @@
 // Released under an MIT license. See LICENSE.
 
-// +build plan9
+//go:build plan9
 
 package system
 
@@ -1,6 +1,6 @@
 // Released under an MIT license. See LICENSE.
 
-// +build linux darwin dragonfly freebsd openbsd netbsd solaris
+//go:build linux || darwin || dragonfly || freebsd || openbsd || netbsd || solaris
 
 package system
 
@@ -57,6 +57,10 @@ func SetForegroundGroup(group int) {
 		syscall.TIOCSPGRP, uintptr(unsafe.Pointer(&group)))
 }
 
+func SignalProcess(pid int, sig os.Signal) error {
+	return syscall.Kill(pid, sig.(syscall.Signal))
+}
+
 func SuspendProcess(pid int) {
 	syscall.Kill(pid, syscall.SIGSTOP)
 }
@@ -1,6 +1,6 @@
 // Released under an MIT license. See LICENSE.
 
-// +build windows
+//go:build windows
 
 package system
 
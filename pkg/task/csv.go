@@ -0,0 +1,238 @@
+// Released under an MIT license. See LICENSE.
+
+package task
+
+import (
+	"bufio"
+	. "github.com/michaelmacinnis/oh/pkg/cell"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+ * Dialect definition.
+ * (A dialect describes the delimiter, quote and header of CSV records).
+ */
+
+type dialect struct {
+	delim  rune
+	quote  rune
+	header bool
+}
+
+func newDialect(args Cell) *dialect {
+	d := &dialect{delim: ',', quote: '"'}
+
+	for ; args != Null; args = Cdr(args) {
+		switch o := Raw(Car(args)); o {
+		case "-d", "-q":
+			args = Cdr(args)
+			if args == Null {
+				panic("option " + o + " requires an argument")
+			}
+
+			r, n := utf8.DecodeRuneInString(Raw(Car(args)))
+			if n == 0 || n != len(Raw(Car(args))) {
+				panic("option " + o + " requires a single character")
+			}
+
+			if o == "-d" {
+				d.delim = r
+			} else {
+				d.quote = r
+			}
+
+		case "-h":
+			d.header = true
+
+		default:
+			panic("unknown option " + o)
+		}
+	}
+
+	if d.delim == d.quote || d.delim == '\n' || d.quote == '\n' {
+		panic("invalid delimiter or quote")
+	}
+
+	return d
+}
+
+/* Dialect-specific functions. */
+
+func (d *dialect) format(fields []string) string {
+	l := make([]string, len(fields))
+	for i, s := range fields {
+		q := string(d.quote)
+		if strings.ContainsAny(s, string(d.delim)+q+"\r\n") {
+			s = q + strings.Replace(s, q, q+q, -1) + q
+		}
+		l[i] = s
+	}
+
+	return strings.Join(l, string(d.delim))
+}
+
+func (d *dialect) parse(r *bufio.Reader) ([]string, bool) {
+	fields := []string{}
+
+	var b strings.Builder
+	quoted, started := false, false
+
+	for {
+		c, _, err := r.ReadRune()
+		if err == io.EOF {
+			if !started {
+				return nil, false
+			}
+			if quoted {
+				panic("unterminated quoted field")
+			}
+			return append(fields, b.String()), true
+		} else if err != nil {
+			panic(err.Error())
+		}
+
+		switch {
+		case quoted:
+			if c != d.quote {
+				b.WriteRune(c)
+			} else if n, _, err := r.ReadRune(); err == nil && n == d.quote {
+				b.WriteRune(c)
+			} else {
+				if err == nil {
+					r.UnreadRune()
+				}
+				quoted = false
+			}
+
+		case c == d.quote && b.Len() == 0:
+			quoted, started = true, true
+
+		case c == d.delim:
+			fields = append(fields, b.String())
+			b.Reset()
+			started = true
+
+		case c == '\r':
+			if n, _, err := r.ReadRune(); err == nil && n != '\n' {
+				r.UnreadRune()
+				b.WriteRune(c)
+			} else if err == nil {
+				r.UnreadRune()
+			}
+
+		case c == '\n':
+			if !started {
+				continue
+			}
+			return append(fields, b.String()), true
+
+		default:
+			b.WriteRune(c)
+			started = true
+		}
+	}
+}
+
+func (d *dialect) read(t *Task, in, out Conduit) {
+	var r *bufio.Reader
+	if p, ok := in.(*Pipe); ok {
+		r = p.reader()
+	} else {
+		r = bufio.NewReader(&lineReader{in: in, t: t})
+	}
+
+	var header []string
+	for fields, ok := d.parse(r); ok; fields, ok = d.parse(r) {
+		if d.header && header == nil {
+			header = fields
+			continue
+		}
+
+		if header == nil {
+			l := Null
+			for i := len(fields) - 1; i >= 0; i-- {
+				l = Cons(NewString(fields[i]), l)
+			}
+			out.Write(List(l))
+			continue
+		}
+
+		m := NewMap()
+		for i, s := range fields {
+			var k Cell = NewInteger(int64(i))
+			if i < len(header) {
+				k = NewString(header[i])
+			}
+			m.Set(k, NewString(s))
+		}
+		out.Write(List(m))
+	}
+}
+
+func (d *dialect) write(t *Task, in, out Conduit) {
+	first := true
+	for l := in.Read(t); l != Null; l = in.Read(t) {
+		if Cdr(l) == Null && !IsAtom(Car(l)) {
+			l = Car(l)
+		}
+
+		var keys, values Cell
+		switch r := l.(type) {
+		case *Map:
+			keys, values = r.Keys(), r.Values()
+		case *Vector:
+			values = r.List()
+		default:
+			values = l
+		}
+
+		if first && d.header && keys != nil {
+			out.Write(List(NewSymbol(d.format(list2strings(keys)))))
+		}
+		first = false
+
+		out.Write(List(NewSymbol(d.format(list2strings(values)))))
+	}
+}
+
+/*
+ * Line reader definition.
+ * (A line reader presents the values read from a conduit as lines of text).
+ */
+
+type lineReader struct {
+	b  []byte
+	in Conduit
+	t  *Task
+}
+
+/* Line reader-specific functions. */
+
+func (r *lineReader) Read(p []byte) (int, error) {
+	for len(r.b) == 0 {
+		l := r.in.Read(r.t)
+		if l == Null {
+			return 0, io.EOF
+		}
+
+		for ; l != Null; l = Cdr(l) {
+			r.b = append(r.b, Raw(Car(l))+"\n"...)
+		}
+	}
+
+	n := copy(p, r.b)
+	r.b = r.b[n:]
+
+	return n, nil
+}
+
+func list2strings(l Cell) []string {
+	s := []string{}
+	for ; l != Null; l = Cdr(l) {
+		s = append(s, Raw(Car(l)))
+	}
+
+	return s
+}
@@ -6,6 +6,8 @@ package task
 
 import (
 	. "github.com/michaelmacinnis/oh/pkg/cell"
+	"math"
+	"math/big"
 	"strings"
 	"unicode"
 )
@@ -66,6 +68,193 @@ func bindArithmetic(s *Scope) {
 
 		return t.Return(acc)
 	})
+
+	s.DefineMethod("band", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, -1)
+		acc := integral(Car(args))
+
+		for Cdr(args) != Null {
+			args = Cdr(args)
+			acc = new(big.Int).And(acc, integral(Car(args)))
+		}
+
+		return t.Return(NewBigInteger(acc))
+	})
+
+	s.DefineMethod("bor", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, -1)
+		acc := integral(Car(args))
+
+		for Cdr(args) != Null {
+			args = Cdr(args)
+			acc = new(big.Int).Or(acc, integral(Car(args)))
+		}
+
+		return t.Return(NewBigInteger(acc))
+	})
+
+	s.DefineMethod("bxor", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, -1)
+		acc := integral(Car(args))
+
+		for Cdr(args) != Null {
+			args = Cdr(args)
+			acc = new(big.Int).Xor(acc, integral(Car(args)))
+		}
+
+		return t.Return(NewBigInteger(acc))
+	})
+
+	s.DefineMethod("max", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, -1, IsNumber)
+		acc := Car(args)
+
+		for Cdr(args) != Null {
+			args = Cdr(args)
+			if Car(args).(Atom).Rat().Cmp(acc.(Atom).Rat()) > 0 {
+				acc = Car(args)
+			}
+		}
+
+		return t.Return(acc)
+	})
+
+	s.DefineMethod("min", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, -1, IsNumber)
+		acc := Car(args)
+
+		for Cdr(args) != Null {
+			args = Cdr(args)
+			if Car(args).(Atom).Rat().Cmp(acc.(Atom).Rat()) < 0 {
+				acc = Car(args)
+			}
+		}
+
+		return t.Return(acc)
+	})
+
+	s.DefineMethod("shl", func(t *Task, args Cell) bool {
+		t.Validate(args, 2, 2, IsNumber, IsNumber)
+		v := integral(Car(args))
+
+		return t.Return(NewBigInteger(new(big.Int).Lsh(v, count(Cadr(args)))))
+	})
+
+	s.DefineMethod("shr", func(t *Task, args Cell) bool {
+		t.Validate(args, 2, 2, IsNumber, IsNumber)
+		v := integral(Car(args))
+
+		return t.Return(NewBigInteger(new(big.Int).Rsh(v, count(Cadr(args)))))
+	})
+
+	s.DefineMethod("abs", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1, IsNumber, IsNumber)
+
+		return t.Return(absolute(Car(args)))
+	})
+
+	s.DefineMethod("bnot", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1, IsNumber, IsNumber)
+
+		return t.Return(NewBigInteger(new(big.Int).Not(integral(Car(args)))))
+	})
+
+	s.DefineMethod("pow", func(t *Task, args Cell) bool {
+		t.Validate(args, 2, 2, IsNumber, IsNumber)
+
+		return t.Return(power(Car(args), Cadr(args)))
+	})
+}
+
+func bindMath(e Context) {
+
+	e.PublicMethod("cos", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1, IsNumber)
+
+		return t.Return(NewFloat(math.Cos(Car(args).(Atom).Float())))
+	})
+
+	e.PublicMethod("exp", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1, IsNumber)
+
+		return t.Return(NewFloat(math.Exp(Car(args).(Atom).Float())))
+	})
+
+	e.PublicMethod("log", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1, IsNumber)
+
+		return t.Return(NewFloat(math.Log(Car(args).(Atom).Float())))
+	})
+
+	e.PublicMethod("log10", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1, IsNumber)
+
+		return t.Return(NewFloat(math.Log10(Car(args).(Atom).Float())))
+	})
+
+	e.PublicMethod("log2", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1, IsNumber)
+
+		return t.Return(NewFloat(math.Log2(Car(args).(Atom).Float())))
+	})
+
+	e.PublicMethod("sin", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1, IsNumber)
+
+		return t.Return(NewFloat(math.Sin(Car(args).(Atom).Float())))
+	})
+
+	e.PublicMethod("sqrt", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1, IsNumber)
+
+		return t.Return(NewFloat(math.Sqrt(Car(args).(Atom).Float())))
+	})
+
+	e.PublicMethod("tan", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1, IsNumber)
+
+		return t.Return(NewFloat(math.Tan(Car(args).(Atom).Float())))
+	})
+
+	e.PublicMethod("ceil", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1, IsNumber)
+
+		return t.Return(rounded(Car(args), math.Ceil))
+	})
+
+	e.PublicMethod("floor", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1, IsNumber)
+
+		return t.Return(rounded(Car(args), math.Floor))
+	})
+
+	e.PublicMethod("round", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1, IsNumber)
+
+		return t.Return(rounded(Car(args), math.Round))
+	})
+
+	e.PublicMethod("trunc", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1, IsNumber)
+
+		return t.Return(rounded(Car(args), math.Trunc))
+	})
+
+	e.PublicMethod("atan2", func(t *Task, args Cell) bool {
+		t.Validate(args, 2, 2, IsNumber, IsNumber)
+
+		return t.Return(NewFloat(math.Atan2(Car(args).(Atom).Float(), Cadr(args).(Atom).Float())))
+	})
+
+	e.PublicMethod("pow", func(t *Task, args Cell) bool {
+		t.Validate(args, 2, 2, IsNumber, IsNumber)
+
+		return t.Return(power(Car(args), Cadr(args)))
+	})
+
+	e.Public(NewSymbol("e"), NewFloat(math.E))
+
+	e.Public(NewSymbol("pi"), NewFloat(math.Pi))
 }
 
 func bindGenerators(s *Scope) {
@@ -79,7 +268,7 @@ func bindGenerators(s *Scope) {
 	})
 
 	s.DefineMethod("integer", func(t *Task, args Cell) bool {
-		return t.Return(NewInteger(Car(args).(Atom).Int()))
+		return t.Return(ToInteger(Car(args).(Atom)))
 	})
 
 	s.DefineMethod("pipe", func(t *Task, args Cell) bool {
@@ -137,6 +326,10 @@ func bindPredicates(s *Scope) {
 		return t.Return(NewBoolean(IsInteger(Car(args))))
 	})
 
+	s.DefineMethod("is-map", func(t *Task, args Cell) bool {
+		return t.Return(NewBoolean(IsMap(Car(args))))
+	})
+
 	s.DefineMethod("is-method", func(t *Task, args Cell) bool {
 		return t.Return(NewBoolean(IsMethod(Car(args))))
 	})
@@ -161,6 +354,10 @@ func bindPredicates(s *Scope) {
 		return t.Return(NewBoolean(IsRational(Car(args))))
 	})
 
+	s.DefineMethod("is-set", func(t *Task, args Cell) bool {
+		return t.Return(NewBoolean(IsSet(Car(args))))
+	})
+
 	s.DefineMethod("is-status", func(t *Task, args Cell) bool {
 		return t.Return(NewBoolean(IsStatus(Car(args))))
 	})
@@ -176,6 +373,10 @@ func bindPredicates(s *Scope) {
 	s.DefineMethod("is-syntax", func(t *Task, args Cell) bool {
 		return t.Return(NewBoolean(IsSyntax(Car(args))))
 	})
+
+	s.DefineMethod("is-vector", func(t *Task, args Cell) bool {
+		return t.Return(NewBoolean(IsVector(Car(args))))
+	})
 }
 
 func bindRelational(s *Scope) {
@@ -305,10 +506,18 @@ func bindTheRest(s *Scope) {
 		return t.Return(Cons(Car(args), Cadr(args)))
 	})
 
+	s.DefineMethod("hash-set", func(t *Task, args Cell) bool {
+		return t.Return(list2set(args))
+	})
+
 	s.DefineMethod("list", func(t *Task, args Cell) bool {
 		return t.Return(args)
 	})
 
+	s.DefineMethod("_map_", func(t *Task, args Cell) bool {
+		return t.Return(NewMap())
+	})
+
 	s.DefineMethod("not", func(t *Task, args Cell) bool {
 		return t.Return(NewBoolean(!Car(args).Bool()))
 	})
@@ -316,6 +525,10 @@ func bindTheRest(s *Scope) {
 	s.DefineMethod("reverse", func(t *Task, args Cell) bool {
 		return t.Return(Reverse(Car(args)))
 	})
+
+	s.DefineMethod("vector", func(t *Task, args Cell) bool {
+		return t.Return(list2vector(args))
+	})
 }
 
 func bindStringPredicates(e Context) {
@@ -481,4 +694,76 @@ func bindStringPredicates(e Context) {
 
 		return t.Return(NewString(strings.ToUpper(s)))
 	})
+
+	e.PublicMethod("contains", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1, IsText)
+		s := Raw(toString(Car(t.Dump).(Binding).Self()))
+
+		return t.Return(NewBoolean(strings.Contains(s, Raw(Car(args)))))
+	})
+
+	e.PublicMethod("has-prefix", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1, IsText)
+		s := Raw(toString(Car(t.Dump).(Binding).Self()))
+
+		return t.Return(NewBoolean(strings.HasPrefix(s, Raw(Car(args)))))
+	})
+
+	e.PublicMethod("has-suffix", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1, IsText)
+		s := Raw(toString(Car(t.Dump).(Binding).Self()))
+
+		return t.Return(NewBoolean(strings.HasSuffix(s, Raw(Car(args)))))
+	})
+
+	e.PublicMethod("index", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1, IsText)
+		s := Raw(toString(Car(t.Dump).(Binding).Self()))
+
+		i := strings.Index(s, Raw(Car(args)))
+
+		return t.Return(NewInteger(runeIndex(s, i)))
+	})
+
+	e.PublicMethod("last-index", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1, IsText)
+		s := Raw(toString(Car(t.Dump).(Binding).Self()))
+
+		i := strings.LastIndex(s, Raw(Car(args)))
+
+		return t.Return(NewInteger(runeIndex(s, i)))
+	})
+
+	e.PublicMethod("trim", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 1, IsText)
+		s := Raw(toString(Car(t.Dump).(Binding).Self()))
+
+		if args == Null {
+			return t.Return(NewString(strings.TrimFunc(s, unicode.IsSpace)))
+		}
+
+		return t.Return(NewString(strings.Trim(s, Raw(Car(args)))))
+	})
+
+	e.PublicMethod("trim-left", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 1, IsText)
+		s := Raw(toString(Car(t.Dump).(Binding).Self()))
+
+		if args == Null {
+			return t.Return(NewString(strings.TrimLeftFunc(s, unicode.IsSpace)))
+		}
+
+		return t.Return(NewString(strings.TrimLeft(s, Raw(Car(args)))))
+	})
+
+	e.PublicMethod("trim-right", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 1, IsText)
+		s := Raw(toString(Car(t.Dump).(Binding).Self()))
+
+		if args == Null {
+			return t.Return(NewString(strings.TrimRightFunc(s, unicode.IsSpace)))
+		}
+
+		return t.Return(NewString(strings.TrimRight(s, Raw(Car(args)))))
+	})
 }
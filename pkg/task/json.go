@@ -0,0 +1,185 @@
+// Released under an MIT license. See LICENSE.
+
+package task
+
+import (
+	"encoding/json"
+	. "github.com/michaelmacinnis/oh/pkg/cell"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+func decode(d *json.Decoder) Cell {
+	tok, err := d.Token()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	switch v := tok.(type) {
+	case json.Delim:
+		switch v {
+		case '{':
+			m := NewMap()
+			for d.More() {
+				k, err := d.Token()
+				if err != nil {
+					panic(err.Error())
+				}
+				m.Set(NewString(k.(string)), decode(d))
+			}
+			d.Token()
+
+			return m
+
+		case '[':
+			l := []Cell{}
+			for d.More() {
+				l = append(l, decode(d))
+			}
+			d.Token()
+
+			return NewVector(l...)
+		}
+
+	case bool:
+		return NewBoolean(v)
+
+	case json.Number:
+		if i, ok := new(big.Int).SetString(string(v), 10); ok {
+			return NewBigInteger(i)
+		}
+
+		f, err := v.Float64()
+		if err != nil {
+			panic(err.Error())
+		}
+
+		return NewFloat(f)
+
+	case string:
+		return NewString(v)
+	}
+
+	return NewSymbol("null")
+}
+
+func encode(c Cell) string {
+	switch t := c.(type) {
+	case *Boolean:
+		return strconv.FormatBool(t.Bool())
+
+	case *BigInteger, *Integer, *Status:
+		return t.String()
+
+	case *Float, Rational:
+		f := t.(Atom).Float()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			panic(t.String() + " cannot be encoded as JSON")
+		}
+
+		return strconv.FormatFloat(f, 'g', -1, 64)
+
+	case *Map:
+		l := []string{}
+		for e := t.Entries(); e != Null; e = Cdr(e) {
+			l = append(l, quote(Raw(Caar(e)))+":"+encode(Cadr(Car(e))))
+		}
+
+		return "{" + strings.Join(l, ",") + "}"
+
+	case *Pair:
+		return encodeList(t)
+
+	case *Set:
+		return encodeList(t.List())
+
+	case *String:
+		return quote(Raw(t))
+
+	case *Symbol:
+		s := Raw(t)
+		if s == "null" {
+			return s
+		}
+
+		if s != "" && strings.ContainsRune("-0123456789", rune(s[0])) &&
+			json.Valid([]byte(s)) {
+			return s
+		}
+
+		return quote(s)
+
+	case *Vector:
+		return encodeList(t.List())
+
+	case Context:
+		l := []string{}
+		for e := publics(t).Entries(); e != Null; e = Cdr(e) {
+			if _, ok := Cadr(Car(e)).(Binding); !ok {
+				l = append(l, quote(Raw(Caar(e)))+":"+encode(Cadr(Car(e))))
+			}
+		}
+
+		return "{" + strings.Join(l, ",") + "}"
+	}
+
+	panic(c.String() + " cannot be encoded as JSON")
+}
+
+func encodeList(c Cell) string {
+	l := []string{}
+	for ; c != Null; c = Cdr(c) {
+		l = append(l, encode(Car(c)))
+	}
+
+	return "[" + strings.Join(l, ",") + "]"
+}
+
+func fromJSON(text string) Cell {
+	d := json.NewDecoder(strings.NewReader(text))
+	d.UseNumber()
+
+	c := decode(d)
+	if _, err := d.Token(); err != io.EOF {
+		panic("unexpected data after JSON value")
+	}
+
+	return c
+}
+
+func quote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func streamFromJSON(t *Task, in, out Conduit) {
+	if p, ok := in.(*Pipe); ok {
+		d := json.NewDecoder(p.reader())
+		d.UseNumber()
+
+		for d.More() {
+			out.Write(List(decode(d)))
+		}
+
+		return
+	}
+
+	for l := in.Read(t); l != Null; l = in.Read(t) {
+		for ; l != Null; l = Cdr(l) {
+			out.Write(List(fromJSON(Raw(Car(l)))))
+		}
+	}
+}
+
+func streamToJSON(t *Task, in, out Conduit) {
+	for l := in.Read(t); l != Null; l = in.Read(t) {
+		if Cdr(l) == Null {
+			l = Car(l)
+		}
+
+		out.Write(List(NewSymbol(encode(l))))
+	}
+}
@@ -0,0 +1,376 @@
+// Released under an MIT license. See LICENSE.
+
+package task
+
+import (
+	. "github.com/michaelmacinnis/oh/pkg/cell"
+	"github.com/michaelmacinnis/oh/pkg/common"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+/*
+ * Expression definition.
+ * (An expression is an infix arithmetic expression evaluated by math).
+ */
+
+type expression struct {
+	frame   Cell
+	lexical Cell
+	text    string
+	tokens  []string
+}
+
+func calculate(t *Task, text string) Cell {
+	e := &expression{
+		frame:   t.Frame,
+		lexical: t.Lexical,
+		text:    text,
+		tokens:  tokenize(text),
+	}
+
+	if len(e.tokens) == 0 {
+		e.malformed()
+	}
+
+	r := e.comparison()
+	if len(e.tokens) != 0 {
+		e.malformed()
+	}
+
+	return r
+}
+
+/* Expression-specific functions. */
+
+func (e *expression) additive() Cell {
+	acc := e.multiplicative()
+
+	for {
+		switch e.peek() {
+		case "+":
+			e.next()
+			acc = arithmetic(acc, e.multiplicative(), Number.Add)
+		case "-":
+			e.next()
+			acc = arithmetic(acc, e.multiplicative(), Number.Subtract)
+		default:
+			return acc
+		}
+	}
+}
+
+func (e *expression) comparison() Cell {
+	acc := e.additive()
+
+	for {
+		op := e.peek()
+		switch op {
+		case "<", "<=", ">", ">=", "==", "!=":
+			e.next()
+		default:
+			return acc
+		}
+
+		l := e.number(acc)
+		r := e.number(e.additive())
+
+		v := false
+		switch op {
+		case "<":
+			v = l.Less(r)
+		case "<=":
+			v = !l.Greater(r)
+		case ">":
+			v = l.Greater(r)
+		case ">=":
+			v = !l.Less(r)
+		case "==":
+			v = l.Equal(r)
+		case "!=":
+			v = !l.Equal(r)
+		}
+
+		acc = NewBoolean(v)
+	}
+}
+
+func (e *expression) malformed() {
+	panic(common.ErrSyntax + "Malformed expression: " + e.text)
+}
+
+func (e *expression) multiplicative() Cell {
+	acc := e.unary()
+
+	for {
+		switch e.peek() {
+		case "*":
+			e.next()
+			acc = arithmetic(acc, e.unary(), Number.Multiply)
+		case "/":
+			e.next()
+			acc = arithmetic(acc, e.unary(), Number.Divide)
+		case "%":
+			e.next()
+			acc = arithmetic(acc, e.unary(), Number.Modulo)
+		default:
+			return acc
+		}
+	}
+}
+
+func (e *expression) next() string {
+	if len(e.tokens) == 0 {
+		e.malformed()
+	}
+
+	s := e.tokens[0]
+	e.tokens = e.tokens[1:]
+
+	return s
+}
+
+func (e *expression) number(c Cell) Number {
+	n, ok := c.(Number)
+	if !ok || IsSymbol(c) {
+		e.malformed()
+	}
+
+	return n
+}
+
+func (e *expression) peek() string {
+	if len(e.tokens) == 0 {
+		return ""
+	}
+
+	return e.tokens[0]
+}
+
+func (e *expression) primary() Cell {
+	s := e.next()
+
+	switch {
+	case s == "(":
+		r := e.comparison()
+		if e.next() != ")" {
+			e.malformed()
+		}
+		return r
+
+	case unicode.IsDigit(rune(s[0])) || s[0] == '.':
+		n := literal(s)
+		if n == nil {
+			e.malformed()
+		}
+		return n
+
+	case s[0] == '$' || s[0] == '_' || unicode.IsLetter(rune(s[0])):
+		return e.variable(s)
+	}
+
+	e.malformed()
+
+	return nil
+}
+
+func (e *expression) unary() Cell {
+	switch e.peek() {
+	case "-":
+		e.next()
+		return arithmetic(NewInteger(0), e.unary(), Number.Subtract)
+	case "+":
+		e.next()
+		return e.number(e.unary())
+	}
+
+	return e.primary()
+}
+
+func (e *expression) variable(name string) Cell {
+	name = strings.TrimPrefix(name, "$")
+
+	c, _ := Resolve(e.lexical, e.frame, NewSymbol(name))
+	if c == nil {
+		c, _ = Resolve(e.lexical, e.frame, NewSymbol("$"+name))
+	}
+	if c == nil {
+		panic("'" + name + "' undefined")
+	}
+
+	v := c.Get()
+	switch v.(type) {
+	case *String, *Symbol:
+		if n := literal(Raw(v)); n != nil {
+			return n
+		}
+	case Number:
+		return v
+	}
+
+	panic("'" + name + "' is not a number")
+}
+
+func absolute(c Cell) Number {
+	if f, ok := c.(*Float); ok {
+		return NewFloat(math.Abs(float64(*f)))
+	}
+
+	r := c.(Atom).Rat()
+	if r.IsInt() {
+		return NewBigInteger(new(big.Int).Abs(r.Num()))
+	}
+
+	return NewRational(new(big.Rat).Abs(r))
+}
+
+func arithmetic(l, r Cell, op func(Number, Cell) Number) Cell {
+	a, ok := l.(Number)
+	if !ok {
+		panic("operand is not a number")
+	}
+
+	b, ok := r.(Number)
+	if !ok {
+		panic("operand is not a number")
+	}
+
+	n := op(a, b)
+	if IsFloat(l) || IsFloat(r) {
+		return NewFloat(n.Float())
+	}
+
+	return n
+}
+
+func count(c Cell) uint {
+	n := integral(c)
+	if n.Sign() < 0 || !n.IsUint64() {
+		panic("invalid shift count: " + n.String())
+	}
+
+	return uint(n.Uint64())
+}
+
+func integral(c Cell) *big.Int {
+	a, ok := c.(Atom)
+	if !ok {
+		panic("not an integer")
+	}
+
+	r := a.Rat()
+	if !r.IsInt() {
+		panic(a.String() + " is not an integer")
+	}
+
+	return r.Num()
+}
+
+func literal(s string) Number {
+	base := 10
+	if len(s) > 2 && s[0] == '0' && unicode.IsLetter(rune(s[1])) {
+		base = 0
+	}
+
+	if i, ok := new(big.Int).SetString(s, base); ok {
+		return NewBigInteger(i)
+	}
+
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return NewFloat(f)
+	}
+
+	return nil
+}
+
+func power(b, e Cell) Number {
+	x := e.(Atom).Rat()
+	if IsFloat(b) || IsFloat(e) || !x.IsInt() {
+		return NewFloat(math.Pow(b.(Atom).Float(), e.(Atom).Float()))
+	}
+
+	r := b.(Atom).Rat()
+	n := new(big.Int).Abs(x.Num())
+
+	num := new(big.Int).Exp(r.Num(), n, nil)
+	den := new(big.Int).Exp(r.Denom(), n, nil)
+	if x.Sign() < 0 {
+		num, den = den, num
+	}
+	if den.Sign() == 0 {
+		panic("division by zero")
+	}
+
+	v := new(big.Rat).SetFrac(num, den)
+	if v.IsInt() {
+		return NewBigInteger(v.Num())
+	}
+
+	return NewRational(v)
+}
+
+func rounded(c Cell, f func(float64) float64) Number {
+	if IsInteger(c) {
+		return c.(Number)
+	}
+
+	v := f(c.(Atom).Float())
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		panic(c.String() + " cannot be rounded to an integer")
+	}
+
+	i, _ := big.NewFloat(v).Int(nil)
+
+	return NewBigInteger(i)
+}
+
+func tokenize(s string) []string {
+	tokens := []string{}
+
+	r := []rune(s)
+	for i := 0; i < len(r); {
+		start := i
+
+		switch c := r[i]; {
+		case unicode.IsSpace(c):
+			i++
+			continue
+
+		case unicode.IsDigit(c) || c == '.':
+			for i < len(r) && (r[i] == '.' || r[i] == '_' ||
+				unicode.IsLetter(r[i]) || unicode.IsDigit(r[i])) {
+				i++
+			}
+			decimal := !strings.ContainsAny(string(r[start:i]), "xX")
+			if decimal && i < len(r) && strings.ContainsRune("+-", r[i]) &&
+				strings.ContainsRune("eE", r[i-1]) {
+				for i++; i < len(r) && unicode.IsDigit(r[i]); i++ {
+				}
+			}
+
+		case c == '$' || c == '_' || unicode.IsLetter(c):
+			for i++; i < len(r); i++ {
+				if r[i] != '_' && !unicode.IsLetter(r[i]) &&
+					!unicode.IsDigit(r[i]) {
+					break
+				}
+			}
+
+		case strings.ContainsRune("<>=!", c):
+			i++
+			if i < len(r) && r[i] == '=' {
+				i++
+			}
+
+		default:
+			i++
+		}
+
+		tokens = append(tokens, string(r[start:i]))
+	}
+
+	return tokens
+}
@@ -1,17 +1,28 @@
 // Released under an MIT license. See LICENSE.
 
-// +build !linux,!darwin,!dragonfly,!freebsd,!openbsd,!netbsd,!solaris
+//go:build !linux && !darwin && !dragonfly && !freebsd && !openbsd && !netbsd && !solaris
 
 package task
 
 import (
 	. "github.com/michaelmacinnis/oh/pkg/cell"
+	"os"
+	"strconv"
 )
 
+var signals = map[string]os.Signal{
+	"SIGINT":  os.Interrupt,
+	"SIGKILL": os.Kill,
+}
+
 func initPlatformSpecific() {}
 
 func initSignalHandling() {}
 
+func signalNumber(n int) string {
+	panic("unknown signal " + strconv.Itoa(n))
+}
+
 func evaluate(c Cell, file string, line int, problem string) (Cell, bool) {
 	task0.Eval <- Message{Cmd: c, File: file, Line: line, Problem: problem}
 	return <-task0.Done, task0.Stack != Null
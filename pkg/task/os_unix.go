@@ -1,6 +1,6 @@
 // Released under an MIT license. See LICENSE.
 
-// +build linux darwin dragonfly freebsd openbsd netbsd solaris
+//go:build linux || darwin || dragonfly || freebsd || openbsd || netbsd || solaris
 
 package task
 
@@ -8,6 +8,7 @@ import (
 	. "github.com/michaelmacinnis/oh/pkg/cell"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 )
 
@@ -26,6 +27,24 @@ var (
 	eval0    chan Message
 	incoming chan os.Signal
 	register chan registration
+	signals  = map[string]os.Signal{
+		"SIGALRM":  syscall.SIGALRM,
+		"SIGCHLD":  syscall.SIGCHLD,
+		"SIGCONT":  syscall.SIGCONT,
+		"SIGHUP":   syscall.SIGHUP,
+		"SIGINT":   syscall.SIGINT,
+		"SIGKILL":  syscall.SIGKILL,
+		"SIGPIPE":  syscall.SIGPIPE,
+		"SIGQUIT":  syscall.SIGQUIT,
+		"SIGSTOP":  syscall.SIGSTOP,
+		"SIGTERM":  syscall.SIGTERM,
+		"SIGTSTP":  syscall.SIGTSTP,
+		"SIGTTIN":  syscall.SIGTTIN,
+		"SIGTTOU":  syscall.SIGTTOU,
+		"SIGUSR1":  syscall.SIGUSR1,
+		"SIGUSR2":  syscall.SIGUSR2,
+		"SIGWINCH": syscall.SIGWINCH,
+	}
 )
 
 func broker() {
@@ -139,6 +158,16 @@ func initSignalHandling() {
 	go broker()
 }
 
+func signalNumber(n int) string {
+	for k, v := range signals {
+		if v == syscall.Signal(n) {
+			return k
+		}
+	}
+
+	panic("unknown signal " + strconv.Itoa(n))
+}
+
 func monitor(active chan bool, notify chan notification) {
 	for {
 		monitoring := <-active
@@ -0,0 +1,202 @@
+// Released under an MIT license. See LICENSE.
+
+package task
+
+import (
+	. "github.com/michaelmacinnis/oh/pkg/cell"
+	"sort"
+	"strings"
+)
+
+func field(t *Task, r, k Cell) Cell {
+	if _, ok := k.(Binding); ok {
+		return invoke(t, k, r)
+	}
+
+	switch c := r.(type) {
+	case *Map:
+		if IsSymbol(k) {
+			k = named(c, k)
+		}
+		return c.Get(k, Null)
+
+	case *Pair:
+		if IsNumber(k) {
+			var l Cell = c
+			for i := k.(Atom).Int(); i > 0 && l != Null; i-- {
+				l = Cdr(l)
+			}
+			return Car(l)
+		}
+
+	case *Vector:
+		if IsNumber(k) {
+			return c.Get(k.(Atom).Int(), Null)
+		}
+
+	case Context:
+		v, _ := Resolve(c, nil, NewSymbol(Raw(k)))
+		if v != nil {
+			return v.Get()
+		}
+	}
+
+	return Null
+}
+
+func lines(t *Task, in Conduit, f func(s string)) {
+	for {
+		l := in.ReadLine(t)
+		if l == Null || l == False {
+			return
+		}
+
+		f(strings.TrimRight(Raw(l), "\r"))
+	}
+}
+
+func records(t *Task, in Conduit, f func(r Cell)) {
+	for l := in.Read(t); l != Null; l = in.Read(t) {
+		if Cdr(l) == Null {
+			l = Car(l)
+		}
+
+		f(l)
+	}
+}
+
+func streamCount(t *Task, in Conduit) (n int64) {
+	records(t, in, func(r Cell) {
+		n++
+	})
+
+	return n
+}
+
+func streamEach(t *Task, f Cell, in, out Conduit) {
+	records(t, in, func(r Cell) {
+		out.Write(List(invoke(t, f, r)))
+	})
+}
+
+func streamFirst(t *Task, n int64, in, out Conduit) {
+	records(t, in, func(r Cell) {
+		if n > 0 {
+			out.Write(List(r))
+		}
+		n--
+	})
+}
+
+func streamFromColumns(t *Task, header bool, in, out Conduit) {
+	var keys []string
+	lines(t, in, func(s string) {
+		fields := strings.Fields(s)
+		if len(fields) == 0 {
+			return
+		}
+
+		if header && keys == nil {
+			keys = fields
+			return
+		}
+
+		if keys == nil {
+			l := make([]Cell, len(fields))
+			for i, f := range fields {
+				l[i] = NewString(f)
+			}
+			out.Write(List(List(l...)))
+			return
+		}
+
+		m := NewMap()
+		for i, k := range keys {
+			if i >= len(fields) {
+				break
+			}
+
+			f := fields[i]
+			if i == len(keys)-1 {
+				f = strings.Join(fields[i:], " ")
+			}
+
+			m.Set(NewString(k), NewString(f))
+		}
+		out.Write(List(m))
+	})
+}
+
+func streamFromLines(t *Task, in, out Conduit) {
+	lines(t, in, func(s string) {
+		out.Write(List(NewString(s)))
+	})
+}
+
+func streamGroupBy(t *Task, k Cell, in, out Conduit) {
+	groups := NewMap()
+	records(t, in, func(r Cell) {
+		v := field(t, r, k)
+		groups.Set(v, Cons(r, groups.Get(v, Null)))
+	})
+
+	for e := groups.Entries(); e != Null; e = Cdr(e) {
+		m := NewMap()
+		m.Set(NewString("key"), Caar(e))
+		m.Set(NewString("group"), Reverse(Cadr(Car(e))))
+		out.Write(List(m))
+	}
+}
+
+func streamLast(t *Task, n int64, in, out Conduit) {
+	l := []Cell{}
+	records(t, in, func(r Cell) {
+		l = append(l, r)
+		if int64(len(l)) > n {
+			l = l[1:]
+		}
+	})
+
+	for _, r := range l {
+		out.Write(List(r))
+	}
+}
+
+func streamSelectKeys(t *Task, keys Cell, in, out Conduit) {
+	records(t, in, func(r Cell) {
+		m := NewMap()
+		for l := keys; l != Null; l = Cdr(l) {
+			m.Set(Car(l), field(t, r, Car(l)))
+		}
+		out.Write(List(m))
+	})
+}
+
+func streamSortBy(t *Task, k Cell, in, out Conduit) {
+	l := []Cell{}
+	v := []Cell{}
+	records(t, in, func(r Cell) {
+		l = append(l, r)
+		v = append(v, field(t, r, k))
+	})
+
+	i := make([]int, len(l))
+	for n := range i {
+		i[n] = n
+	}
+	sort.SliceStable(i, func(a, b int) bool {
+		return ordered(v[i[a]], v[i[b]])
+	})
+
+	for _, n := range i {
+		out.Write(List(l[n]))
+	}
+}
+
+func streamWhere(t *Task, f Cell, in, out Conduit) {
+	records(t, in, func(r Cell) {
+		if invoke(t, f, r).Bool() {
+			out.Write(List(r))
+		}
+	})
+}
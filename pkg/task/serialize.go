@@ -0,0 +1,152 @@
+// Released under an MIT license. See LICENSE.
+
+package task
+
+import (
+	. "github.com/michaelmacinnis/oh/pkg/cell"
+	"strings"
+)
+
+func deserialize(c Cell) Cell {
+	if !IsCons(c) || c == Null {
+		return c
+	}
+
+	if IsSymbol(Car(c)) {
+		switch Raw(Car(c)) {
+		case "interpolate":
+			s, ok := Cadr(c).(*String)
+			if ok && Cddr(c) == Null {
+				v := strings.Replace(Raw(s), "$$", "", -1)
+				if !strings.Contains(v, "$") {
+					return NewString(strings.Replace(Raw(s), "$$", "$", -1))
+				}
+			}
+
+		case "_literal_":
+			return deserializeLiteral(Cadr(c), Cddr(c))
+		}
+	}
+
+	return deserializeList(c)
+}
+
+func deserializeList(c Cell) Cell {
+	l := []Cell{}
+	for ; IsCons(c) && c != Null; c = Cdr(c) {
+		l = append(l, deserialize(Car(c)))
+	}
+
+	r := deserialize(c)
+	for i := len(l) - 1; i >= 0; i-- {
+		r = Cons(l[i], r)
+	}
+
+	return r
+}
+
+func deserializeLiteral(kind, args Cell) Cell {
+	switch Raw(kind) {
+	case "list":
+		return deserializeList(args)
+
+	case "map":
+		m := NewMap()
+		for ; args != Null; args = Cdr(args) {
+			m.Set(deserialize(Caar(args)), deserialize(Cadr(Car(args))))
+		}
+
+		return m
+
+	case "object":
+		o := NewScope(object0, nil)
+		for ; args != Null; args = Cdr(args) {
+			o.Public(Caar(args), deserialize(Cadr(Car(args))))
+		}
+
+		return NewObject(o)
+
+	case "set":
+		return list2set(deserialize(args))
+
+	case "vector":
+		return list2vector(deserialize(args))
+	}
+
+	panic("unknown literal " + kind.String())
+}
+
+func serialize(c Cell) string {
+	switch t := c.(type) {
+	case *BigInteger, *Boolean, *Float, *Integer, Rational,
+		*Status, *Symbol:
+		return t.String()
+
+	case *Map:
+		l := []string{"_literal_ map"}
+		for e := t.Entries(); e != Null; e = Cdr(e) {
+			l = append(l, serializeEntry(Caar(e), Cadr(Car(e))))
+		}
+
+		return "(" + strings.Join(l, " ") + ")"
+
+	case *Pair:
+		if t != Null && IsSymbol(Car(t)) {
+			switch Raw(Car(t)) {
+			case "_literal_", "interpolate":
+				return "_literal_ list " + serializeList(t)
+			}
+		}
+
+		return serializeList(t)
+
+	case *Set:
+		return "(_literal_ set " + serializeList(t.List()) + ")"
+
+	case *String:
+		return NewString(strings.Replace(Raw(t), "$", "$$", -1)).String()
+
+	case *Vector:
+		return "(_literal_ vector " + serializeList(t.List()) + ")"
+
+	case Context:
+		l := []string{"_literal_ object"}
+		for e := publics(t).Entries(); e != Null; e = Cdr(e) {
+			l = append(l, serializeEntry(Caar(e), Cadr(Car(e))))
+		}
+
+		return "(" + strings.Join(l, " ") + ")"
+	}
+
+	s := "method"
+	if _, ok := c.(Binding); !ok {
+		s = c.String()
+		if strings.HasPrefix(s, "%") {
+			s = strings.Fields(s[1:])[0]
+		}
+	}
+
+	panic("cannot serialize " + s)
+}
+
+func serializeEntry(k, v Cell) string {
+	return "(" + serializeList(List(k, v)) + ")"
+}
+
+func serializeList(c Cell) string {
+	l := []string{}
+	for ; IsCons(c) && c != Null; c = Cdr(c) {
+		s := serialize(Car(c))
+		if IsCons(Car(c)) && IsCons(Cdr(Car(c))) {
+			s = "(" + s + ")"
+		}
+		l = append(l, s)
+	}
+
+	s := strings.Join(l, " ")
+	if c != Null {
+		s += "::" + serialize(c)
+	}
+
+	return s
+}
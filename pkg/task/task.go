@@ -15,6 +15,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"runtime"
 	"sort"
@@ -22,6 +23,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 type Binding interface {
@@ -118,18 +121,25 @@ const (
 	psEvalMember
 
 	psExecBuiltin
+	psExecCase
+	psExecCaseClause
+	psExecCaseMatch
 	psExecCommand
 	psExecDefine
 	psExecIf
 	psExecMethod
+	psExecParallel
 	psExecPublic
+	psExecSelect
 	psExecSet
 	psExecSplice
 	psExecSyntax
+	psExecTimeout
 	psExecWhileBody
 	psExecWhileTest
 
 	psFatal
+	psInvoke
 	psReturn
 
 	psMax
@@ -138,8 +148,13 @@ const (
 
 var (
 	envc        Context
+	envm        Context
+	envn        Context
 	envp        Context
 	envs        Context
+	envk        Context
+	envt        Context
+	envv        Context
 	frame0      Cell
 	external    Cell
 	home        = "-"
@@ -147,10 +162,14 @@ var (
 	interactive = false
 	jobs        = map[int]*Task{}
 	jobsl       = &sync.RWMutex{}
+	maxRepeat   = 1 << 26
 	namespace   Context
+	object0     *Scope
 	oldpwdsym   *Symbol
 	parse       parser
 	pwdsym      *Symbol
+	regexps     = map[string]*regexp.Regexp{}
+	regexpsl    = &sync.RWMutex{}
 	runnable    chan bool
 	scope0      *Scope
 	sys         Context
@@ -187,7 +206,7 @@ func (b *Bound) Equal(c Cell) bool {
 }
 
 func (b *Bound) String() string {
-	return fmt.Sprintf("%%bound %p%%", b)
+	return Handle("bound", b)
 }
 
 /* Bound-specific functions */
@@ -244,7 +263,7 @@ func (b *Builtin) Equal(c Cell) bool {
 }
 
 func (b *Builtin) String() string {
-	return fmt.Sprintf("%%builtin %p%%", b)
+	return Handle("builtin", b)
 }
 
 /* Channel cell definition. */
@@ -279,7 +298,7 @@ func (ch *Channel) Equal(c Cell) bool {
 }
 
 func (ch *Channel) String() string {
-	return fmt.Sprintf("%%channel %p%%", ch)
+	return Handle("channel", ch)
 }
 
 func (ch *Channel) Close() {
@@ -390,7 +409,7 @@ func (ct *Continuation) Equal(c Cell) bool {
 }
 
 func (ct *Continuation) String() string {
-	return fmt.Sprintf("%%continuation %p%%", ct)
+	return Handle("continuation", ct)
 }
 
 /* Env definition. */
@@ -491,6 +510,44 @@ func (e *Env) Remove(key Cell) bool {
 	return ok
 }
 
+/*
+ * Escape definition.
+ * (An escape carries a continuation out of a method invoked by a builtin).
+ */
+
+type escape struct {
+	c *Continuation
+	v Cell
+}
+
+/* Escape-specific functions */
+
+func (e *escape) reaches(base Cell) bool {
+	if e.c == nil {
+		return false
+	}
+
+	for s := e.c.Stack; s != Null; s = Cdr(s) {
+		if s == base {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+ * Failure definition.
+ * (A failure carries an exception raised in another task or a return
+ * that escaped from it).
+ */
+
+type failure struct {
+	exception Cell
+	message   string
+	escape    *escape
+}
+
 /* Job definition. */
 
 type Job struct {
@@ -542,7 +599,7 @@ func (m *Method) Equal(c Cell) bool {
 }
 
 func (m *Method) String() string {
-	return fmt.Sprintf("%%method %p%%", m)
+	return Handle("method", m)
 }
 
 /*
@@ -569,7 +626,7 @@ func (o *Object) Equal(c Cell) bool {
 }
 
 func (o *Object) String() string {
-	return fmt.Sprintf("%%object %p%%", o)
+	return Handle("object", o)
 }
 
 /* Object-specific functions */
@@ -616,7 +673,10 @@ type Pipe struct {
 	b *bufio.Reader
 	c chan Cell
 	d chan bool
+	e string
 	r *os.File
+	s bool
+	v bool
 	w *os.File
 }
 
@@ -648,6 +708,8 @@ func NewPipe(r *os.File, w *os.File) *Pipe {
 		if p.r, p.w, err = os.Pipe(); err != nil {
 			p.r, p.w = nil, nil
 		}
+
+		p.v = true
 	}
 
 	runtime.SetFinalizer(p, (*Pipe).Close)
@@ -664,7 +726,7 @@ func (p *Pipe) Equal(c Cell) bool {
 }
 
 func (p *Pipe) String() string {
-	return fmt.Sprintf("%%pipe %p%%", p)
+	return Handle("pipe", p)
 }
 
 func (p *Pipe) Close() {
@@ -697,12 +759,38 @@ func (p *Pipe) Read(t *Task) Cell {
 		return Null
 	}
 
+	return p.received(<-p.receiver(t))
+}
+
+func (p *Pipe) ReadLine(t *Task) Cell {
+	s, err := p.reader().ReadString('\n')
+	if err != nil && len(s) == 0 {
+		p.b = nil
+		return Null
+	}
+
+	return NewString(strings.TrimRight(s, "\n"))
+}
+
+func (p *Pipe) received(c Cell) Cell {
+	p.s = false
+
+	if c == nil {
+		panic(p.e)
+	}
+
+	return c
+}
+
+func (p *Pipe) receiver(t *Task) chan Cell {
 	if p.d == nil {
 		p.d = make(chan bool)
-	} else {
+	} else if !p.s {
 		p.d <- true
 	}
 
+	p.s = true
+
 	if p.c == nil {
 		p.c = make(chan Cell)
 		go func() {
@@ -711,10 +799,17 @@ func (p *Pipe) Read(t *Task) Cell {
 				f = p.r
 			}
 			parse(
-				p.reader(), t.Throw, f, p.r.Name(),
+				p.reader(),
+				func(file string, line int, text string) {
+					t.Line = line
+					p.e = text
+					p.c <- nil
+					<-p.d
+				},
+				f, p.r.Name(),
 				func(c Cell, f string, l int, u string) (Cell, bool) {
 					t.Line = l
-					p.c <- c
+					p.c <- deserialize(c)
 					<-p.d
 					return nil, true
 				},
@@ -725,17 +820,7 @@ func (p *Pipe) Read(t *Task) Cell {
 		}()
 	}
 
-	return <-p.c
-}
-
-func (p *Pipe) ReadLine(t *Task) Cell {
-	s, err := p.reader().ReadString('\n')
-	if err != nil && len(s) == 0 {
-		p.b = nil
-		return Null
-	}
-
-	return NewString(strings.TrimRight(s, "\n"))
+	return p.c
 }
 
 func (p *Pipe) WriterClose() {
@@ -750,7 +835,13 @@ func (p *Pipe) Write(c Cell) {
 		panic("write to closed pipe")
 	}
 
-	fmt.Fprintln(p.w, c)
+	// Only values written to pipes created by oh are read back.
+	if !p.v {
+		fmt.Fprintln(p.w, c)
+		return
+	}
+
+	fmt.Fprintln(p.w, serialize(c))
 }
 
 /* Pipe-specific functions */
@@ -995,7 +1086,7 @@ func (s *Scope) Equal(c Cell) bool {
 }
 
 func (s *Scope) String() string {
-	return fmt.Sprintf("%%scope %p%%", s)
+	return Handle("scope", s)
 }
 
 /* Scope-specific functions */
@@ -1124,7 +1215,7 @@ func (m *Syntax) Equal(c Cell) bool {
 }
 
 func (m *Syntax) String() string {
-	return fmt.Sprintf("%%syntax %p%%", m)
+	return Handle("syntax", m)
 }
 
 /* Task cell definition. */
@@ -1134,10 +1225,15 @@ type Task struct {
 	Registers
 	Done      chan Cell
 	Eval      chan Message
+	base      Cell
+	boundary  Context
 	children  map[*Task]bool
 	childrenl *sync.RWMutex
+	failed    chan *failure
+	outer     []Reference
 	parent    *Task
 	pid       int
+	stopped   chan bool
 	suspended chan bool
 }
 
@@ -1148,12 +1244,14 @@ func NewTask(c Cell, l Context, p *Task) *Task {
 
 	var frame Cell
 	var j *Job
+	file, line := "oh", 0
 	if p == nil {
 		frame = frame0
 		j = NewJob()
 	} else {
 		frame = p.Frame
 		j = p.Job
+		file, line = p.File, p.Line
 	}
 
 	t := &Task{
@@ -1163,8 +1261,8 @@ func NewTask(c Cell, l Context, p *Task) *Task {
 				Dump:  List(ExitSuccess),
 				Frame: frame,
 				Stack: List(NewInteger(psEvalBlock)),
-				File:  "oh",
-				Line:  0,
+				File:  file,
+				Line:  line,
 			},
 			Code:    c,
 			Lexical: l,
@@ -1175,6 +1273,7 @@ func NewTask(c Cell, l Context, p *Task) *Task {
 		childrenl: &sync.RWMutex{},
 		parent:    p,
 		pid:       0,
+		stopped:   make(chan bool),
 		suspended: runnable,
 	}
 
@@ -1192,7 +1291,7 @@ func (t *Task) Bool() bool {
 }
 
 func (t *Task) String() string {
-	return fmt.Sprintf("%%task %p%%", t)
+	return Handle("task", t)
 }
 
 func (t *Task) Equal(c Cell) bool {
@@ -1223,12 +1322,48 @@ func (t *Task) Apply(args Cell) bool {
 	}
 
 	params := m.Ref().Params()
-	for args != Null && params != Null && IsAtom(Car(params)) {
-		c.Define(Car(params), Car(args))
-		args, params = Cdr(args), Cdr(params)
+	named, args := keywords(params, args)
+
+	var minimum, maximum int64
+	for l := params; l != Null; l = Cdr(l) {
+		p := Car(l)
+		if IsCons(p) && Cdr(p) == Null {
+			maximum = -1
+		} else if named[Raw(parameter(p))] == nil {
+			if IsAtom(p) {
+				minimum++
+			}
+			if maximum >= 0 {
+				maximum++
+			}
+		}
+	}
+	t.Validate(args, minimum, maximum)
+
+	defaults := []Cell{}
+	for ; params != Null; params = Cdr(params) {
+		p := Car(params)
+		if IsCons(p) && Cdr(p) == Null {
+			c.Define(Car(p), args)
+			break
+		}
+
+		k := parameter(p)
+		if v := named[Raw(k)]; v != nil {
+			c.Define(k, v)
+		} else if args != Null {
+			c.Define(k, Car(args))
+			args = Cdr(args)
+		} else {
+			defaults = append(defaults, List(k, Cadr(p)))
+		}
 	}
-	if IsCons(Car(params)) {
-		c.Define(Caar(params), args)
+
+	if len(defaults) > 0 {
+		r, _ := Resolve(scope0, nil, NewSymbol("define"))
+		for i := len(defaults) - 1; i >= 0; i-- {
+			t.Code = Cons(Cons(r.Get(), defaults[i]), t.Code)
+		}
 	}
 
 	cc := NewContinuation(Cdr(t.Dump), t.Frame, t.Stack, t.File, t.Line)
@@ -1282,6 +1417,16 @@ func (t *Task) Closure(n ClosureGenerator) bool {
 		panic(common.ErrSyntax + "expected '='")
 	}
 
+	for l := params; l != Null; l = Cdr(l) {
+		p := Car(l)
+		if IsSymbol(p) || IsCons(p) && IsSymbol(Car(p)) &&
+			(Cddr(p) == Null || Cdr(p) == Null && Cdr(l) == Null) {
+			continue
+		}
+
+		panic(common.ErrSyntax + "unexpected parameter " + p.String())
+	}
+
 	body := t.Code
 	scope := toContext(t.Lexical)
 
@@ -1296,8 +1441,8 @@ func (t *Task) Closure(n ClosureGenerator) bool {
 }
 
 func (t *Task) Continue() {
-	if t.pid > 0 {
-		system.ContinueProcess(t.pid)
+	if pid := t.processID(); pid > 0 {
+		system.ContinueProcess(pid)
 	}
 
 	t.childrenl.RLock()
@@ -1339,8 +1484,16 @@ func (t *Task) Execute(arg0 string, argv []string, attr *os.ProcAttr) (*Status,
 
 	t.Unlock()
 
+	select {
+	case <-t.stopped:
+		system.TerminateProcess(proc.Pid)
+	default:
+	}
+
 	status := exitStatus(proc)
 
+	t.Lock()
+
 	if jobControlEnabled() {
 		if t.Group == t.pid {
 			t.Group = 0
@@ -1348,6 +1501,8 @@ func (t *Task) Execute(arg0 string, argv []string, attr *os.ProcAttr) (*Status,
 	}
 	t.pid = 0
 
+	t.Unlock()
+
 	return status, err
 }
 
@@ -1487,6 +1642,16 @@ func (t *Task) LexicalVar(state int64) bool {
 
 func (t *Task) Lookup(sym *Symbol, simple bool) (bool, string) {
 	c, s := Resolve(t.Lexical, t.Frame, sym)
+	if t.boundary != nil && Raw(sym) == "throw" {
+		/* Don't let an isolated task run its parent's handlers. */
+		for _, r := range t.outer {
+			if c == r {
+				c, s = Resolve(t.boundary, nil, sym)
+				break
+			}
+		}
+	}
+
 	if c == nil {
 		r := Raw(sym)
 		if t.GetState() == psEvalMember || (t.Strict() && !number(r)) {
@@ -1504,6 +1669,41 @@ func (t *Task) Lookup(sym *Symbol, simple bool) (bool, string) {
 	return true, ""
 }
 
+func (t *Task) processID() int {
+	t.Lock()
+	defer t.Unlock()
+
+	return t.pid
+}
+
+func (t *Task) Rethrow(c Cell, text string) {
+	throw := NewSymbol("throw")
+
+	var resolved Reference = nil
+
+	/* Unwind stack until we can resolve 'throw'. */
+	for t.Lexical != scope0 {
+		state := t.GetState()
+		if state <= 0 {
+			t.Lexical = scope0
+			break
+		}
+
+		switch t.Lexical.(type) {
+		case Context:
+			resolved, _ = Resolve(t.Lexical, t.Frame, throw)
+		}
+
+		if resolved != nil {
+			break
+		}
+
+		t.RemoveState()
+	}
+
+	Call(t, List(throw, c), text)
+}
+
 func (t *Task) Run(end Cell, problem string) (status int) {
 	status = 0
 
@@ -1513,7 +1713,29 @@ func (t *Task) Run(end Cell, problem string) (status int) {
 			return
 		}
 
-		if problem == "" {
+		if e, ok := r.(*escape); ok && e.c == nil {
+			t.Dump = List(e.v)
+			status = -1
+			return
+		} else if ok && t.failed != nil && !e.reaches(t.base) {
+			select {
+			case t.failed <- &failure{escape: e}:
+			default:
+			}
+
+			status = -1
+			return
+		} else if ok {
+			t.Continuation = *e.c
+			t.Dump = Cons(e.v, t.Dump)
+
+			status = t.Run(end, problem)
+			return
+		}
+
+		if f, ok := r.(*failure); ok && problem == "" {
+			t.Rethrow(f.exception, f.message)
+		} else if problem == "" {
 			t.Throw(t.File, t.Line, fmt.Sprintf("%v", r))
 		} else {
 			println("Catastrophic error: " + problem)
@@ -1522,7 +1744,48 @@ func (t *Task) Run(end Cell, problem string) (status int) {
 		status = 1
 	}()
 
+	return t.step(end)
+}
+
+func (t *Task) Runnable() bool {
+	select {
+	case <-t.suspended:
+	case <-t.stopped:
+		return false
+	}
+
+	select {
+	case <-t.stopped:
+		return false
+	default:
+		return true
+	}
+}
+
+func (t *Task) Self() Cell {
+	return Car(t.Dump).(Binding).Self()
+}
+
+func (t *Task) Signal(sig os.Signal) {
+	if pid := t.processID(); pid > 0 {
+		system.SignalProcess(pid, sig)
+	}
+
+	t.childrenl.RLock()
+	for k, v := range t.children {
+		if v {
+			k.Signal(sig)
+		}
+	}
+	t.childrenl.RUnlock()
+}
+
+func (t *Task) step(end Cell) int {
 	for t.Runnable() && t.Stack != Null {
+		if t == task0 {
+			trapSignal(t)
+		}
+
 		state := t.GetState()
 
 		switch state {
@@ -1547,6 +1810,60 @@ func (t *Task) Run(end Cell, problem string) (status int) {
 				continue
 			}
 
+		case psExecCase:
+			t.Code = Cdr(t.Code)
+
+			fallthrough
+		case psExecCaseClause:
+			if t.Code == Null {
+				SetCar(t.Dump, False)
+				break
+			}
+
+			clause := Car(t.Code)
+			switch Raw(Car(clause)) {
+			case "default":
+				t.Code = Cdr(clause)
+
+			case "glob", "regex", "type":
+				t.ReplaceStates(psExecCaseMatch, SaveCode, psEvalElement)
+				t.Code = Cadr(clause)
+				continue
+
+			case "list":
+				if !fits(Cadr(clause), Car(t.Dump)) {
+					t.ReplaceStates(psExecCaseClause)
+					t.Code = Cdr(t.Code)
+					continue
+				}
+
+				c := toContext(t.Lexical)
+				destructure(Cadr(clause), Car(t.Dump), c.Define)
+				t.Code = Cddr(clause)
+
+			default:
+				msg := "unexpected case clause '" + Raw(Car(clause)) + "'"
+				panic(common.ErrSyntax + msg)
+			}
+
+			t.ReplaceStates(psEvalBlock)
+			continue
+
+		case psExecCaseMatch:
+			p := Car(t.Dump)
+			t.Dump = Cdr(t.Dump)
+
+			clause := Car(t.Code)
+			if !matches(t, Raw(Car(clause)), p, Car(t.Dump)) {
+				t.ReplaceStates(psExecCaseClause)
+				t.Code = Cdr(t.Code)
+				continue
+			}
+
+			t.ReplaceStates(psEvalBlock)
+			t.Code = Cddr(clause)
+			continue
+
 		case psExecIf, psExecWhileBody:
 			if !Car(t.Dump).Bool() {
 				t.Code = Cdr(t.Code)
@@ -1575,7 +1892,7 @@ func (t *Task) Run(end Cell, problem string) (status int) {
 		case psEvalBlock:
 			if t.Code == end {
 				//t.Dump = Cdr(t.Dump)
-				return
+				return 0
 			}
 
 			if t.Code == Null ||
@@ -1677,25 +1994,83 @@ func (t *Task) Run(end Cell, problem string) (status int) {
 			}
 
 		case psExecDefine:
-			toContext(t.Lexical).Define(t.Code, Car(t.Dump))
+			c := toContext(t.Lexical)
+			destructure(t.Code, Car(t.Dump), c.Define)
+
+		case psExecParallel:
+			body := Cddr(t.Code)
+
+			n := int64(runtime.NumCPU())
+			if IsAtom(Car(body)) {
+				w := Car(body)
+				if sym, ok := w.(*Symbol); ok && !IsNumber(w) {
+					r, _ := Resolve(t.Lexical, t.Frame, sym)
+					if r != nil {
+						w = r.Get()
+					}
+				}
+
+				if !IsNumber(w) || w.(Atom).Int() < 1 {
+					panic("invalid number of workers " + w.String())
+				}
+
+				n = w.(Atom).Int()
+				body = Cdr(body)
+			}
+
+			l := parallel(t, Car(t.Code), Car(t.Dump), body, n)
+			SetCar(t.Dump, l)
 
 		case psExecPublic:
-			toContext(t.Lexical).Public(t.Code, Car(t.Dump))
+			c := toContext(t.Lexical)
+			destructure(t.Code, Car(t.Dump), c.Public)
 
-		case psExecSet:
-			k := t.Code.(*Symbol)
-			r, _ := Resolve(t.Lexical, t.Frame, k)
-			if r == nil {
-				msg := "'" + k.String() + "' undefined"
-				panic(msg)
+		case psExecSelect:
+			clause, v := choose(t, t.Code, Car(t.Dump))
+			if clause == nil {
+				break
+			}
+
+			SetCar(t.Dump, v)
+
+			switch Raw(Car(clause)) {
+			case "default":
+				t.Code = Cdr(clause)
+
+			case "done", "timeout":
+				t.Code = Cddr(clause)
+
+			case "read":
+				c := toContext(t.Lexical)
+				destructure(Caddr(clause), v, c.Define)
+
+				fallthrough
+			case "write":
+				t.Code = Cdr(Cddr(clause))
 			}
 
-			r.Set(Car(t.Dump))
+			t.ReplaceStates(psEvalBlock)
+			continue
+
+		case psExecSet:
+			destructure(t.Code, Car(t.Dump), func(k, v Cell) {
+				r, _ := Resolve(t.Lexical, t.Frame, k.(*Symbol))
+				if r == nil {
+					msg := "'" + k.String() + "' undefined"
+					panic(msg)
+				}
+
+				r.Set(v)
+			})
 
 		case psExecSplice:
 			l := Car(t.Dump)
 			t.Dump = Cdr(t.Dump)
 
+			if v, ok := l.(*Vector); ok {
+				l = v.List()
+			}
+
 			if !IsCons(l) {
 				t.Dump = Cons(l, t.Dump)
 				break
@@ -1706,6 +2081,39 @@ func (t *Task) Run(end Cell, problem string) (status int) {
 				l = Cdr(l)
 			}
 
+		case psExecTimeout:
+			d := duration(Car(t.Dump))
+
+			c := toContext(t.Lexical)
+			failed := make(chan *failure, 1)
+			child := isolate(t, Cdr(t.Code), NewScope(c, nil), failed)
+
+			go child.Launch()
+
+			select {
+			case <-child.Done:
+			case <-t.stopped:
+				child.Stop()
+			case <-time.After(d):
+				child.Stop()
+				panic(common.ErrTimeout + "timed out after " + d.String())
+			}
+
+			t.childrenl.Lock()
+			delete(t.children, child)
+			t.childrenl.Unlock()
+
+			select {
+			case f := <-failed:
+				if f.escape != nil {
+					panic(f.escape)
+				}
+				panic(f)
+			default:
+			}
+
+			SetCar(t.Dump, Car(child.Dump))
+
 		case psExecWhileTest:
 			t.ReplaceStates(psExecWhileBody,
 				SaveCode,
@@ -1718,11 +2126,19 @@ func (t *Task) Run(end Cell, problem string) (status int) {
 		case psFatal:
 			return -1
 
+		case psInvoke:
+			break
+
 		case psReturn:
 			args := t.Arguments()
 
-			t.Continuation = *Car(t.Dump).(*Continuation)
-			t.Dump = Cons(Car(args), t.Dump)
+			e := &escape{Car(t.Dump).(*Continuation), Car(args)}
+			if t.base != nil && !e.reaches(t.base) {
+				panic(e)
+			}
+
+			t.Continuation = *e.c
+			t.Dump = Cons(e.v, t.Dump)
 
 			break
 
@@ -1740,29 +2156,28 @@ func (t *Task) Run(end Cell, problem string) (status int) {
 		t.RemoveState()
 	}
 
-	return
-}
-
-func (t *Task) Runnable() bool {
-	return !<-t.suspended
-}
-
-func (t *Task) Self() Cell {
-	return Car(t.Dump).(Binding).Self()
+	return 0
 }
 
 func (t *Task) Stop() {
-	t.Stack = Null
-	close(t.Eval)
+	t.Lock()
 
 	select {
-	case <-t.suspended:
+	case <-t.stopped:
+		t.Unlock()
+		return
 	default:
-		close(t.suspended)
+		close(t.stopped)
 	}
 
-	if t.pid > 0 {
-		system.TerminateProcess(t.pid)
+	pid := t.pid
+
+	t.Unlock()
+
+	close(t.Eval)
+
+	if pid > 0 {
+		system.TerminateProcess(pid)
 	}
 
 	t.childrenl.RLock()
@@ -1793,8 +2208,8 @@ func (t *Task) Strict() (ok bool) {
 }
 
 func (t *Task) Suspend() {
-	if t.pid > 0 {
-		system.SuspendProcess(t.pid)
+	if pid := t.processID(); pid > 0 {
+		system.SuspendProcess(pid)
 	}
 
 	t.childrenl.RLock()
@@ -1809,51 +2224,25 @@ func (t *Task) Suspend() {
 }
 
 func (t *Task) Throw(file string, line int, text string) {
-	throw := NewSymbol("throw")
+	kind := "error/runtime"
+	code := "1"
 
-	var resolved Reference = nil
-
-	/* Unwind stack until we can resolve 'throw'. */
-	for t.Lexical != scope0 {
-		state := t.GetState()
-		if state <= 0 {
-			t.Lexical = scope0
-			break
-		}
-
-		switch t.Lexical.(type) {
-		case Context:
-			resolved, _ = Resolve(t.Lexical, t.Frame, throw)
-		}
-
-		if resolved != nil {
-			break
-		}
-
-		t.RemoveState()
-	}
-
-	kind := "error/runtime"
-	code := "1"
-
-	if strings.HasPrefix(text, "oh: ") {
-		args := strings.SplitN(text, ": ", 4)
-		code = args[1]
-		kind = args[2]
-		text = args[3]
-	}
-	c := List(
-		throw, List(
-			NewSymbol("_exception"),
-			NewSymbol(kind),
-			NewStatus(NewSymbol(code).Status()),
-			NewSymbol(text),
-			NewInteger(int64(line)),
-			NewSymbol(path.Base(file)),
-		),
-	)
-	Call(t, c, text)
-}
+	if strings.HasPrefix(text, "oh: ") {
+		args := strings.SplitN(text, ": ", 4)
+		code = args[1]
+		kind = args[2]
+		text = args[3]
+	}
+	c := List(
+		NewSymbol("_exception"),
+		NewSymbol(kind),
+		NewStatus(NewSymbol(code).Status()),
+		NewSymbol(text),
+		NewInteger(int64(line)),
+		NewSymbol(path.Base(file)),
+	)
+	t.Rethrow(c, text)
+}
 
 func (t *Task) Validate(
 	args Cell, minimum, maximum int64, validators ...Validator,
@@ -1928,7 +2317,7 @@ func (u *Unbound) Equal(c Cell) bool {
 }
 
 func (u *Unbound) String() string {
-	return fmt.Sprintf("%%unbound %p%%", u)
+	return Handle("unbound", u)
 }
 
 /* Unbound-specific functions */
@@ -2093,7 +2482,14 @@ func Start(p parser, cli ui) {
 			"/dev/stdin", 0, "")
 	}
 
-	os.Exit(int(status(Car(task0.Dump)).Int()))
+	code := int(status(Car(task0.Dump)).Int())
+
+	if h := trapHandler("EXIT"); h != nil {
+		LaunchForegroundTask()
+		eval(List(h, NewSymbol("EXIT")), "", -1, "")
+	}
+
+	os.Exit(code)
 }
 
 /* Convert Cell into a Conduit. (Return nil if not possible). */
@@ -2110,14 +2506,24 @@ func asContext(c Cell) Context {
 	switch t := c.(type) {
 	case Context:
 		return t
+	case *BigInteger, *Float, *Integer, Rational, *Status:
+		return numberContext()
 	case *Channel:
 		return conduitContext()
+	case *Map:
+		return mapContext()
 	case *Pair:
 		return pairContext()
 	case *Pipe:
 		return conduitContext()
+	case *Set:
+		return setContext()
 	case *String:
 		return stringContext()
+	case *Task:
+		return taskContext()
+	case *Vector:
+		return vectorContext()
 	}
 	return nil
 }
@@ -2147,6 +2553,152 @@ func braceExpand(arg string) []string {
 	return expanded
 }
 
+func captures(re *regexp.Regexp, m []string) Cell {
+	named := false
+	for _, n := range re.SubexpNames() {
+		if n != "" {
+			named = true
+		}
+	}
+
+	if !named {
+		l := Null
+		for i := len(m) - 1; i >= 0; i-- {
+			l = Cons(NewString(m[i]), l)
+		}
+		return l
+	}
+
+	o := NewScope(object0, nil)
+	for i, n := range re.SubexpNames() {
+		if n != "" {
+			o.Public(NewSymbol(n), NewString(m[i]))
+		}
+	}
+
+	return NewObject(o)
+}
+
+func choose(t *Task, clauses, values Cell) (Cell, Cell) {
+	cases := []reflect.SelectCase{}
+	chosen := []Cell{}
+	sources := []Cell{}
+
+	add := func(clause, source Cell, c reflect.SelectCase) {
+		cases = append(cases, c)
+		chosen = append(chosen, clause)
+		sources = append(sources, source)
+	}
+
+	recv := func(ch interface{}) reflect.SelectCase {
+		return reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(ch),
+		}
+	}
+
+	for ; clauses != Null; clauses = Cdr(clauses) {
+		clause := Car(clauses)
+		source := Car(values)
+
+		switch Raw(Car(clause)) {
+		case "default":
+			add(clause, nil, reflect.SelectCase{Dir: reflect.SelectDefault})
+			continue
+
+		case "done":
+			add(clause, source, recv(toTask(source).Done))
+
+		case "read":
+			switch c := asConduit(source).(type) {
+			case *Channel:
+				add(clause, source, recv(c.v))
+			case *Pipe:
+				if c.r == nil {
+					closed := make(chan Cell)
+					close(closed)
+					add(clause, source, recv(closed))
+				} else {
+					add(clause, source, recv(c.receiver(t)))
+				}
+			default:
+				panic("cannot read from " + source.String())
+			}
+
+		case "timeout":
+			add(clause, source, recv(time.After(duration(source))))
+
+		case "write":
+			c, ok := asConduit(source).(*Channel)
+			if !ok {
+				panic("cannot select on write to " + source.String())
+			}
+			values = Cdr(values)
+
+			v := Car(values)
+			add(clause, source, reflect.SelectCase{
+				Dir:  reflect.SelectSend,
+				Chan: reflect.ValueOf(c.v),
+				Send: reflect.ValueOf(&v).Elem(),
+			})
+		}
+
+		values = Cdr(values)
+	}
+
+	add(nil, nil, recv(t.stopped))
+
+	i, v, ok := reflect.Select(cases)
+
+	clause := chosen[i]
+	if clause == nil {
+		return nil, Null
+	}
+
+	switch Raw(Car(clause)) {
+	case "done":
+		return clause, Car(toTask(sources[i]).Dump)
+
+	case "read":
+		if !ok {
+			return clause, Null
+		}
+
+		c, _ := v.Interface().(Cell)
+		if p, ok := asConduit(sources[i]).(*Pipe); ok {
+			c = p.received(c)
+		}
+
+		return clause, c
+
+	case "write":
+		return clause, True
+	}
+
+	return clause, False
+}
+
+func compile(pattern string) *regexp.Regexp {
+	regexpsl.RLock()
+	re, ok := regexps[pattern]
+	regexpsl.RUnlock()
+
+	if ok {
+		return re
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		panic(err)
+	}
+
+	regexpsl.Lock()
+	regexps[pattern] = re
+	regexpsl.Unlock()
+
+	return re
+}
+
 func conduitContext() Context {
 	if envc != nil {
 		return envc
@@ -2188,6 +2740,13 @@ func conduitContext() Context {
 	return envc
 }
 
+func conduits(t *Task) (Conduit, Conduit) {
+	in, _ := Resolve(t.Lexical, t.Frame, NewSymbol("_stdin_"))
+	out, _ := Resolve(t.Lexical, t.Frame, NewSymbol("_stdout_"))
+
+	return toConduit(in.Get()), toConduit(out.Get())
+}
+
 func control(t *Task, args Cell) *Task {
 	if !jobControlEnabled() || t != task0 {
 		return nil
@@ -2223,6 +2782,76 @@ func control(t *Task, args Cell) *Task {
 	return found
 }
 
+func destructure(p, v Cell, bind func(k, v Cell)) {
+	if !IsCons(p) {
+		bind(p, v)
+		return
+	}
+
+	pattern(p)
+
+	var m *Map
+	switch c := v.(type) {
+	case *Map:
+		m = c
+	case *Vector:
+		v = c.List()
+	case Context:
+		m = publics(c)
+	}
+
+	if m == nil {
+		if !IsCons(v) {
+			panic(v.String() + " cannot be destructured")
+		}
+
+		for ; p != Null && IsSymbol(Car(p)); p = Cdr(p) {
+			if v == Null {
+				panic("not enough values to destructure")
+			}
+			bind(Car(p), Car(v))
+			v = Cdr(v)
+		}
+
+		if p != Null {
+			bind(Caar(p), v)
+		} else if v != Null {
+			panic("too many values to destructure")
+		}
+
+		return
+	}
+
+	rest := NewMap()
+	rest.Merge(m)
+
+	for ; p != Null && IsSymbol(Car(p)); p = Cdr(p) {
+		k := named(m, Car(p))
+		if !m.Has(k) {
+			panic("'" + Raw(k) + "' undefined")
+		}
+		bind(Car(p), m.Get(k, nil))
+		rest.Del(k)
+	}
+
+	if p != Null {
+		bind(Caar(p), rest)
+	}
+}
+
+func duration(c Cell) time.Duration {
+	if IsNumber(c) {
+		return time.Duration(c.(Atom).Float() * float64(time.Second))
+	}
+
+	d, err := time.ParseDuration(Raw(c))
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return d
+}
+
 func expand(t *Task, args Cell) Cell {
 	list := Null
 
@@ -2271,6 +2900,55 @@ func expand(t *Task, args Cell) Cell {
 	return list
 }
 
+func fits(p, v Cell) (ok bool) {
+	if IsCons(p) {
+		pattern(p)
+	}
+
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+
+	destructure(p, v, func(k, v Cell) {})
+
+	return true
+}
+
+func flatten(c, l Cell) Cell {
+	for ; c != Null; c = Cdr(c) {
+		if IsCons(Car(c)) {
+			l = flatten(Car(c), l)
+		} else {
+			l = Cons(Car(c), l)
+		}
+	}
+
+	return l
+}
+
+func graphemes(s string) []string {
+	l := []string{}
+
+	r := []rune(s)
+	for i := 0; i < len(r); {
+		j := i + 1
+		if j < len(r) && regional(r[i]) && regional(r[j]) {
+			j++
+		}
+
+		for j < len(r) && joined(r[j-1], r[j]) {
+			j++
+		}
+
+		l = append(l, string(r[i:j]))
+		i = j
+	}
+
+	return l
+}
+
 func init() {
 	rand.Seed(time.Now().UnixNano())
 
@@ -2322,10 +3000,10 @@ func init() {
 		panic("public members cannot be added to this type")
 	})
 
-	object := NewScope(namespace, nil)
+	object0 = NewScope(namespace, nil)
 
 	/* Standard Methods. */
-	object.PublicMethod("_del_", func(t *Task, args Cell) bool {
+	object0.PublicMethod("_del_", func(t *Task, args Cell) bool {
 		t.Validate(args, 1, 1)
 		self := toContext(t.Self())
 		s := Raw(Car(args))
@@ -2337,7 +3015,7 @@ func init() {
 
 		return t.Return(NewBoolean(ok))
 	})
-	object.PublicMethod("_get_", func(t *Task, args Cell) bool {
+	object0.PublicMethod("_get_", func(t *Task, args Cell) bool {
 		t.Validate(args, 1, 1)
 		s := Raw(Car(args))
 		k := NewSymbol(s)
@@ -2351,7 +3029,7 @@ func init() {
 			return t.Return(c.Get())
 		}
 	})
-	object.PublicMethod("_set_", func(t *Task, args Cell) bool {
+	object0.PublicMethod("_set_", func(t *Task, args Cell) bool {
 		t.Validate(args, 2, 2)
 		s := Raw(Car(args))
 		v := Cadr(args)
@@ -2361,17 +3039,17 @@ func init() {
 		toContext(t.Self()).Public(k, v)
 		return t.Return(v)
 	})
-	object.PublicMethod("child", func(t *Task, args Cell) bool {
+	object0.PublicMethod("child", func(t *Task, args Cell) bool {
 		t.Validate(args, 0, 0)
 		c := toContext(t.Self())
 		return t.Return(NewObject(NewScope(c.Expose(), nil)))
 	})
-	object.PublicMethod("clone", func(t *Task, args Cell) bool {
+	object0.PublicMethod("clone", func(t *Task, args Cell) bool {
 		t.Validate(args, 0, 0)
 		c := toContext(t.Self())
 		return t.Return(NewObject(c.Expose().Copy()))
 	})
-	object.PublicMethod("context", func(t *Task, args Cell) bool {
+	object0.PublicMethod("context", func(t *Task, args Cell) bool {
 		t.Validate(args, 0, 0)
 		self := toContext(t.Self())
 		bare := self.Expose()
@@ -2380,7 +3058,7 @@ func init() {
 		}
 		return t.Return(self)
 	})
-	object.PublicMethod("eval", func(t *Task, args Cell) bool {
+	object0.PublicMethod("eval", func(t *Task, args Cell) bool {
 		t.Validate(args, 1, 1)
 		scope := toContext(t.Self()).Expose()
 		t.RemoveState()
@@ -2394,13 +3072,13 @@ func init() {
 
 		return true
 	})
-	object.PublicMethod("has", func(t *Task, args Cell) bool {
+	object0.PublicMethod("has", func(t *Task, args Cell) bool {
 		t.Validate(args, 1, 1)
 		c, _ := Resolve(t.Self(), nil, NewSymbol(Raw(Car(args))))
 
 		return t.Return(NewBoolean(c != nil))
 	})
-	object.PublicMethod("interpolate", func(t *Task, args Cell) bool {
+	object0.PublicMethod("interpolate", func(t *Task, args Cell) bool {
 		t.Validate(args, 1, 1, IsText)
 		l := toContext(t.Self())
 		if t.Lexical == l.Expose() {
@@ -2411,12 +3089,12 @@ func init() {
 
 		return t.Return(NewString(modified))
 	})
-	object.PublicSyntax("public", func(t *Task, args Cell) bool {
+	object0.PublicSyntax("public", func(t *Task, args Cell) bool {
 		return t.LexicalVar(psExecPublic)
 	})
 
 	/* Root Scope. */
-	scope0 = NewScope(object, nil)
+	scope0 = NewScope(object0, nil)
 
 	/* Arithmetic. */
 	bindArithmetic(scope0)
@@ -2570,6 +3248,18 @@ func init() {
 	/* Relational. */
 	bindRelational(scope0)
 
+	scope0.DefineMethod("csv-read", func(t *Task, args Cell) bool {
+		in, out := conduits(t)
+		newDialect(args).read(t, in, out)
+
+		return t.Return(True)
+	})
+	scope0.DefineMethod("csv-write", func(t *Task, args Cell) bool {
+		in, out := conduits(t)
+		newDialect(args).write(t, in, out)
+
+		return t.Return(True)
+	})
 	scope0.DefineMethod("match", func(t *Task, args Cell) bool {
 		t.Validate(args, 2, 2, IsText, IsText)
 		pattern := Raw(Car(args))
@@ -2605,6 +3295,22 @@ func init() {
 	})
 
 	/* Standard Functions. */
+	scope0.DefineMethod("count", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+
+		in, out := conduits(t)
+		out.Write(List(NewInteger(streamCount(t, in))))
+
+		return t.Return(True)
+	})
+	scope0.DefineMethod("each", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1)
+
+		in, out := conduits(t)
+		streamEach(t, Car(args), in, out)
+
+		return t.Return(True)
+	})
 	scope0.DefineMethod("exit", func(t *Task, args Cell) bool {
 		t.Dump = List(Car(args))
 
@@ -2619,6 +3325,53 @@ func init() {
 
 		return true
 	})
+	scope0.DefineMethod("first", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 1, IsNumber)
+		n := int64(1)
+		if args != Null {
+			n = Car(args).(Atom).Int()
+		}
+
+		in, out := conduits(t)
+		streamFirst(t, n, in, out)
+
+		return t.Return(True)
+	})
+	scope0.DefineMethod("from-columns", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 1)
+		header := false
+		if args != Null {
+			if Raw(Car(args)) != "-h" {
+				panic("unknown option " + Raw(Car(args)))
+			}
+			header = true
+		}
+
+		in, out := conduits(t)
+		streamFromColumns(t, header, in, out)
+
+		return t.Return(True)
+	})
+	scope0.DefineMethod("from-json", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 1)
+
+		if args != Null {
+			return t.Return(fromJSON(Raw(Car(args))))
+		}
+
+		in, out := conduits(t)
+		streamFromJSON(t, in, out)
+
+		return t.Return(True)
+	})
+	scope0.DefineMethod("from-lines", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+
+		in, out := conduits(t)
+		streamFromLines(t, in, out)
+
+		return t.Return(True)
+	})
 	scope0.DefineMethod("get-line-number", func(t *Task, args Cell) bool {
 		t.Validate(args, 0, 0)
 		return t.Return(NewInteger(int64(t.Line)))
@@ -2627,6 +3380,50 @@ func init() {
 		t.Validate(args, 0, 0)
 		return t.Return(NewSymbol(t.File))
 	})
+	scope0.DefineMethod("group-by", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1)
+
+		in, out := conduits(t)
+		streamGroupBy(t, Car(args), in, out)
+
+		return t.Return(True)
+	})
+	scope0.DefineMethod("kill", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, -1)
+
+		name := "SIGTERM"
+		if IsText(Car(args)) && strings.HasPrefix(Raw(Car(args)), "-") {
+			name = signalName(Raw(Car(args))[1:])
+			args = Cdr(args)
+		}
+
+		for ; args != Null; args = Cdr(args) {
+			kill(Car(args), name)
+		}
+
+		return t.Return(True)
+	})
+	scope0.DefineMethod("last", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 1, IsNumber)
+		n := int64(1)
+		if args != Null {
+			n = Car(args).(Atom).Int()
+		}
+
+		in, out := conduits(t)
+		streamLast(t, n, in, out)
+
+		return t.Return(True)
+	})
+	scope0.DefineMethod("math", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, -1)
+		arr := make([]string, 0, Length(args))
+		for ; args != Null; args = Cdr(args) {
+			arr = append(arr, Raw(Car(args)))
+		}
+
+		return t.Return(calculate(t, strings.Join(arr, " ")))
+	})
 	scope0.DefineMethod("open", func(t *Task, args Cell) bool {
 		t.Validate(args, 2, 2, IsText, IsText)
 		mode := Raw(Car(args))
@@ -2680,10 +3477,26 @@ func init() {
 
 		return t.Return(NewPipe(r, w))
 	})
+	scope0.DefineMethod("parameters", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1)
+
+		m, ok := Car(args).(Binding)
+		if !ok {
+			panic(Car(args).String() + " is not a method")
+		}
+
+		return t.Return(m.Ref().Params())
+	})
 	scope0.DefineMethod("random", func(t *Task, args Cell) bool {
 		t.Validate(args, 0, 0)
 		return t.Return(NewFloat(rand.Float64()))
 	})
+	scope0.DefineMethod("select-keys", func(t *Task, args Cell) bool {
+		in, out := conduits(t)
+		streamSelectKeys(t, args, in, out)
+
+		return t.Return(True)
+	})
 	scope0.DefineMethod("set-line-number", func(t *Task, args Cell) bool {
 		t.Validate(args, 1, 1, IsNumber)
 		t.Line = int(Car(args).(Atom).Int())
@@ -2696,6 +3509,14 @@ func init() {
 
 		return false
 	})
+	scope0.DefineMethod("sort-by", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1)
+
+		in, out := conduits(t)
+		streamSortBy(t, Car(args), in, out)
+
+		return t.Return(True)
+	})
 	scope0.DefineMethod("temp-fifo", func(t *Task, args Cell) bool {
 		t.Validate(args, 0, 0)
 		name, err := adapted.TempFifo("fifo-")
@@ -2705,6 +3526,39 @@ func init() {
 
 		return t.Return(NewSymbol(name))
 	})
+	scope0.DefineMethod("to-json", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 1)
+
+		if args != Null {
+			return t.Return(NewString(encode(Car(args))))
+		}
+
+		in, out := conduits(t)
+		streamToJSON(t, in, out)
+
+		return t.Return(True)
+	})
+	scope0.DefineMethod("trap", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 2, IsText)
+
+		if args == Null {
+			return t.Return(trapListing())
+		}
+
+		name := signalName(Raw(Car(args)))
+		if Cdr(args) == Null {
+			trap(name, nil)
+			return t.Return(True)
+		}
+
+		if _, ok := Cadr(args).(Binding); !ok {
+			panic(Cadr(args).String() + " is not a method")
+		}
+
+		trap(name, Cadr(args))
+
+		return t.Return(True)
+	})
 	scope0.DefineMethod("wait", func(t *Task, args Cell) bool {
 		if args == Null {
 			t.Wait()
@@ -2717,8 +3571,16 @@ func init() {
 		}
 		return t.Return(list)
 	})
+	scope0.DefineMethod("where", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1)
 
-	/* Syntax. */
+		in, out := conduits(t)
+		streamWhere(t, Car(args), in, out)
+
+		return t.Return(True)
+	})
+
+	/* Syntax. */
 	scope0.DefineSyntax("block", func(t *Task, args Cell) bool {
 		t.ReplaceStates(SaveLexical, psEvalBlock)
 
@@ -2726,6 +3588,17 @@ func init() {
 
 		return true
 	})
+	scope0.DefineSyntax("case", func(t *Task, args Cell) bool {
+		t.ReplaceStates(SaveLexical,
+			psExecCase, SaveCode, psEvalElement)
+
+		t.NewBlock(toContext(t.Lexical))
+
+		t.Code = Car(t.Code)
+		t.Dump = Cdr(t.Dump)
+
+		return true
+	})
 	scope0.DefineSyntax("if", func(t *Task, args Cell) bool {
 		t.ReplaceStates(SaveLexical,
 			psExecIf, SaveCode, psEvalElement)
@@ -2737,6 +3610,45 @@ func init() {
 
 		return true
 	})
+	scope0.DefineSyntax("parallel", func(t *Task, args Cell) bool {
+		t.ReplaceStates(psExecParallel, SaveCode, psEvalElement)
+
+		t.Code = Cadr(t.Code)
+		t.Dump = Cdr(t.Dump)
+
+		return true
+	})
+	scope0.DefineSyntax("select", func(t *Task, args Cell) bool {
+		l := []Cell{}
+		for c := t.Code; c != Null; c = Cdr(c) {
+			clause := Car(c)
+			switch Raw(Car(clause)) {
+			case "default":
+
+			case "done", "read", "timeout":
+				l = append(l, Cadr(clause))
+
+			case "write":
+				l = append(l, Cadr(clause), Caddr(clause))
+
+			default:
+				msg := "unexpected select clause '" + Raw(Car(clause)) + "'"
+				panic(common.ErrSyntax + msg)
+			}
+		}
+
+		t.ReplaceStates(SaveLexical,
+			psExecSelect, SaveCode, psEvalCommand)
+
+		t.NewBlock(toContext(t.Lexical))
+
+		r, _ := Resolve(scope0, nil, NewSymbol("list"))
+
+		t.Code = Cons(r.Get(), List(l...))
+		t.Dump = Cdr(t.Dump)
+
+		return true
+	})
 	scope0.DefineSyntax("set", func(t *Task, args Cell) bool {
 		t.Dump = Cdr(t.Dump)
 
@@ -2751,7 +3663,7 @@ func init() {
 		}
 
 		t.Code = Car(t.Code)
-		if !IsCons(t.Code) {
+		if !IsCons(t.Code) || IsCons(Cdr(t.Code)) {
 			t.ReplaceStates(psExecSet, SaveCode)
 		} else {
 			t.ReplaceStates(SaveLexical,
@@ -2783,6 +3695,14 @@ func init() {
 
 		return true
 	})
+	scope0.DefineSyntax("timeout", func(t *Task, args Cell) bool {
+		t.ReplaceStates(psExecTimeout, SaveCode, psEvalElement)
+
+		t.Code = Car(t.Code)
+		t.Dump = Cdr(t.Dump)
+
+		return true
+	})
 	scope0.DefineSyntax("while", func(t *Task, args Cell) bool {
 		t.ReplaceStates(SaveLexical, psExecWhileTest)
 
@@ -2792,13 +3712,17 @@ func init() {
 	/* The rest. */
 	bindTheRest(scope0)
 
-	env := NewObject(NewScope(object, nil))
-	sys = NewObject(NewScope(object, nil))
+	env := NewObject(NewScope(object0, nil))
+	lib := NewObject(NewScope(object0, nil))
+	sys = NewObject(NewScope(object0, nil))
+
+	bindMath(lib)
 
 	scope0.Define(NewSymbol("false"), False)
 	scope0.Define(NewSymbol("true"), True)
 
 	scope0.Define(NewSymbol("_env_"), env)
+	scope0.Define(NewSymbol("_math_"), lib)
 	scope0.Define(NewSymbol("_pid_"), NewInteger(int64(system.Pid())))
 	scope0.Define(NewSymbol("_platform_"), NewSymbol(system.Platform))
 	scope0.Define(NewSymbol("_ppid_"), NewInteger(int64(system.Ppid())))
@@ -2848,14 +3772,286 @@ func interpolate(l Context, d Cell, s string) string {
 	return r.ReplaceAllStringFunc(s, f)
 }
 
+func invoke(t *Task, f Cell, args ...Cell) Cell {
+	m, ok := f.(Binding)
+	if !ok {
+		panic(f.String() + " is not a method")
+	}
+
+	state := int64(psExecMethod)
+	switch m.Ref().(type) {
+	case *Builtin:
+		state = psExecBuiltin
+	case *Syntax:
+		panic(f.String() + " is not a method")
+	}
+
+	base, saved := t.base, t.Registers
+	defer func() {
+		t.base, t.Registers = base, saved
+	}()
+
+	t.base = List(NewInteger(psInvoke))
+
+	t.Dump = Cons(nil, List(f))
+	for _, arg := range args {
+		t.Dump = Cons(arg, t.Dump)
+	}
+	t.Stack = Cons(NewInteger(state), t.base)
+
+	for !resume(t) {
+	}
+
+	return Car(t.Dump)
+}
+
+func isolate(p *Task, c Cell, s Context, failed chan *failure) *Task {
+	t := NewTask(c, s, p)
+	t.base = List(NewInteger(psInvoke))
+	t.failed = failed
+	t.Stack = Cons(Car(t.Stack), t.base)
+
+	throw := NewSymbol("throw")
+	if r, _ := Resolve(s, nil, throw); r != nil {
+		t.outer = append(t.outer, r)
+	}
+	for f := t.Frame; f != Null; f = Cdr(f) {
+		if r := toContext(Car(f)).Access(throw); r != nil {
+			t.outer = append(t.outer, r)
+		}
+	}
+
+	t.boundary = NewScope(scope0, nil)
+	t.boundary.DefineMethod("throw", func(t *Task, args Cell) bool {
+		e := Car(args)
+
+		msg := e.String()
+		if c := asContext(e); c != nil {
+			m, _ := Resolve(c, nil, NewSymbol("message"))
+			if m != nil {
+				msg = Raw(m.Get())
+			}
+		}
+
+		select {
+		case failed <- &failure{exception: e, message: msg}:
+		default:
+		}
+
+		t.Stop()
+
+		return true
+	})
+
+	return t
+}
+
 func isSimple(c Cell) bool {
 	return IsAtom(c) || IsCons(c)
 }
 
+func joined(prev, next rune) bool {
+	switch {
+	case prev == '\r' && next == '\n':
+		return true
+	case prev == '\u200d' || next == '\u200d':
+		return true
+	case 0x1f3fb <= next && next <= 0x1f3ff:
+		return true
+	}
+
+	return unicode.Is(unicode.M, next)
+}
+
 func jobControlEnabled() bool {
 	return interactive && system.JobControlSupported()
 }
 
+func keywords(params, args Cell) (map[string]Cell, Cell) {
+	names := map[string]bool{}
+	for ; params != Null; params = Cdr(params) {
+		p := Car(params)
+		if IsAtom(p) || Cdr(p) != Null {
+			names[Raw(parameter(p))] = true
+		}
+	}
+
+	named := map[string]Cell{}
+	positional := []Cell{}
+	for ; args != Null; args = Cdr(args) {
+		a := Car(args)
+		if IsSymbol(a) && Cdr(args) != Null {
+			k := strings.TrimPrefix(Raw(a), "--")
+			if k != Raw(a) && names[k] {
+				args = Cdr(args)
+				named[k] = Car(args)
+				continue
+			}
+		}
+
+		positional = append(positional, a)
+	}
+
+	return named, List(positional...)
+}
+
+func kill(c Cell, name string) {
+	sig := signals[name]
+
+	cancel := false
+	switch name {
+	case "SIGHUP", "SIGINT", "SIGKILL", "SIGQUIT", "SIGTERM":
+		cancel = true
+	}
+
+	found, ok := c.(*Task)
+	if ok {
+		found.Signal(sig)
+	} else if s := Raw(c); !strings.HasPrefix(s, "%") {
+		pid, err := strconv.Atoi(s)
+		if err != nil {
+			panic("invalid process " + s)
+		}
+
+		if err := system.SignalProcess(pid, sig); err != nil {
+			panic(err.Error())
+		}
+
+		return
+	} else {
+		n, _ := strconv.Atoi(s[1:])
+
+		jobsl.Lock()
+		found = jobs[n]
+		if found != nil && cancel {
+			delete(jobs, n)
+		}
+		jobsl.Unlock()
+
+		if found == nil {
+			panic("no such job " + s)
+		}
+
+		group := found.Job.Group
+		if group > 0 {
+			system.SignalProcess(-group, sig)
+			if cancel {
+				system.ContinueProcess(-group)
+			}
+		} else {
+			found.Signal(sig)
+		}
+	}
+
+	if cancel {
+		found.Stop()
+	}
+}
+
+func list2set(l Cell) *Set {
+	s := NewSet()
+	for ; l != Null; l = Cdr(l) {
+		s.Add(Car(l))
+	}
+
+	return s
+}
+
+func list2vector(l Cell) *Vector {
+	v := NewVector()
+	for ; l != Null; l = Cdr(l) {
+		v.Push(Car(l))
+	}
+
+	return v
+}
+
+func mapContext() Context {
+	if envm != nil {
+		return envm
+	}
+
+	envm = NewScope(namespace, nil)
+	envm.PublicMethod("del", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1)
+		return t.Return(NewBoolean(toMap(t.Self()).Del(Car(args))))
+	})
+	envm.PublicMethod("entries", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+		return t.Return(toMap(t.Self()).Entries())
+	})
+	envm.PublicMethod("get", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 2)
+		m := toMap(t.Self())
+
+		var dflt Cell = nil
+		if Cdr(args) != Null {
+			dflt = Cadr(args)
+		}
+
+		return t.Return(m.Get(Car(args), dflt))
+	})
+	envm.PublicMethod("has", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1)
+		return t.Return(NewBoolean(toMap(t.Self()).Has(Car(args))))
+	})
+	envm.PublicMethod("keys", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+		return t.Return(toMap(t.Self()).Keys())
+	})
+	envm.PublicMethod("length", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+		return t.Return(NewInteger(toMap(t.Self()).Length()))
+	})
+	envm.PublicMethod("merge", func(t *Task, args Cell) bool {
+		m := toMap(t.Self())
+
+		for ; args != Null; args = Cdr(args) {
+			m.Merge(toMap(Car(args)))
+		}
+
+		return t.Return(m)
+	})
+	envm.PublicMethod("set", func(t *Task, args Cell) bool {
+		t.Validate(args, 2, 2)
+		v := Cadr(args)
+
+		toMap(t.Self()).Set(Car(args), v)
+
+		return t.Return(v)
+	})
+	envm.PublicMethod("values", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+		return t.Return(toMap(t.Self()).Values())
+	})
+
+	return envm
+}
+
+func matches(t *Task, kind string, p, v Cell) bool {
+	switch kind {
+	case "glob":
+		if !IsAtom(v) {
+			return false
+		}
+
+		ok, err := path.Match(Raw(p), Raw(v))
+		if err != nil {
+			panic(err)
+		}
+
+		return ok
+
+	case "regex":
+		return IsAtom(v) && compile(Raw(p)).MatchString(Raw(v))
+
+	case "type":
+		return invoke(t, p, v).Bool()
+	}
+
+	return false
+}
+
 func module(f string) (string, error) {
 	i, err := os.Stat(f)
 	if err != nil {
@@ -2869,6 +4065,17 @@ func module(f string) (string, error) {
 	return m, nil
 }
 
+// A name matches a symbol key or, failing that, a string key.
+func named(m *Map, k Cell) Cell {
+	if !m.Has(k) {
+		if s := NewString(Raw(k)); m.Has(s) {
+			return s
+		}
+	}
+
+	return k
+}
+
 func namedCount(c int64, n string, p string) string {
 	s := ""
 	if c != 1 {
@@ -2879,16 +4086,108 @@ func namedCount(c int64, n string, p string) string {
 }
 
 func number(s string) bool {
-	m, err := regexp.MatchString(`^[0-9]+(\.[0-9]+)?$`, s)
+	m, err := regexp.MatchString(
+		`^([0-9]+(\.[0-9]+)?|0[xX][0-9a-fA-F]+|0[oO][0-7]+|0[bB][01]+)$`, s,
+	)
 	return err == nil && m
 }
 
+func numberContext() Context {
+	if envn != nil {
+		return envn
+	}
+
+	envn = NewScope(namespace, nil)
+	envn.PublicMethod("format", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 1, IsNumber)
+		base := int64(10)
+		if args != Null {
+			base = Car(args).(Atom).Int()
+		}
+
+		if base < 2 || base > 36 {
+			panic("base must be between 2 and 36")
+		}
+
+		if base == 10 {
+			return t.Return(NewString(t.Self().String()))
+		}
+
+		return t.Return(NewString(integral(t.Self()).Text(int(base))))
+	})
+	envn.PublicMethod("keys", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+		return t.Return(Null)
+	})
+
+	return envn
+}
+
+// Numbers sort first, by value, followed by strings and then all
+// other values, grouped by type.
+func ordered(l, r Cell) bool {
+	lr, rr := sortRank(l), sortRank(r)
+	if lr != rr {
+		return lr < rr
+	}
+
+	switch lr {
+	case 0:
+		return l.(Atom).Rat().Cmp(r.(Atom).Rat()) < 0
+	case 2:
+		lt, rt := fmt.Sprintf("%T", l), fmt.Sprintf("%T", r)
+		if lt != rt {
+			return lt < rt
+		}
+	}
+
+	return Raw(l) < Raw(r)
+}
+
+func padding(s string, args Cell) string {
+	fill := " "
+	if Cdr(args) != Null {
+		fill = Raw(Cadr(args))
+	}
+
+	n := int(Car(args).(Atom).Int()) - utf8.RuneCountInString(s)
+	if n <= 0 || fill == "" {
+		return ""
+	}
+
+	return string([]rune(strings.Repeat(fill, n))[:n])
+}
+
 func pairContext() Context {
 	if envp != nil {
 		return envp
 	}
 
 	envp = NewScope(namespace, nil)
+	envp.PublicMethod("all", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1)
+		var s Cell = toPair(t.Self())
+
+		for ; s != Null; s = Cdr(s) {
+			if !invoke(t, Car(args), Car(s)).Bool() {
+				return t.Return(False)
+			}
+		}
+
+		return t.Return(True)
+	})
+	envp.PublicMethod("any", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1)
+		var s Cell = toPair(t.Self())
+
+		for ; s != Null; s = Cdr(s) {
+			if invoke(t, Car(args), Car(s)).Bool() {
+				return t.Return(True)
+			}
+		}
+
+		return t.Return(False)
+	})
 	envp.PublicMethod("append", func(t *Task, args Cell) bool {
 		t.Validate(args, 1, 1)
 		var s Cell = toPair(t.Self())
@@ -2903,22 +4202,62 @@ func pairContext() Context {
 
 		return t.Return(l)
 	})
-	envp.PublicMethod("get", func(t *Task, args Cell) bool {
-		t.Validate(args, 0, 1, IsNumber)
-		s := toPair(t.Self())
+	envp.PublicMethod("drop-while", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1)
+		var s Cell = toPair(t.Self())
 
-		i := int64(0)
-		if args != Null {
-			i = Car(args).(Atom).Int()
-			args = Cdr(args)
+		for s != Null && invoke(t, Car(args), Car(s)).Bool() {
+			s = Cdr(s)
 		}
 
-		var dflt Cell = nil
-		if args != Null {
-			dflt = args
+		return t.Return(s)
+	})
+	envp.PublicMethod("filter", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1)
+		var s Cell = toPair(t.Self())
+		l := Null
+
+		for ; s != Null; s = Cdr(s) {
+			if invoke(t, Car(args), Car(s)).Bool() {
+				l = Cons(Car(s), l)
+			}
 		}
 
-		return t.Return(Car(Tail(s, i, dflt)))
+		return t.Return(Reverse(l))
+	})
+	envp.PublicMethod("find", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1)
+		var s Cell = toPair(t.Self())
+
+		for ; s != Null; s = Cdr(s) {
+			if invoke(t, Car(args), Car(s)).Bool() {
+				return t.Return(Car(s))
+			}
+		}
+
+		return t.Return(Null)
+	})
+	envp.PublicMethod("flatten", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+		return t.Return(Reverse(flatten(toPair(t.Self()), Null)))
+	})
+	envp.PublicMethod("fold", reduce)
+	envp.PublicMethod("get", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 1, IsNumber)
+		s := toPair(t.Self())
+
+		i := int64(0)
+		if args != Null {
+			i = Car(args).(Atom).Int()
+			args = Cdr(args)
+		}
+
+		var dflt Cell = nil
+		if args != Null {
+			dflt = args
+		}
+
+		return t.Return(Car(Tail(s, i, dflt)))
 	})
 	envp.PublicMethod("head", func(t *Task, args Cell) bool {
 		t.Validate(args, 0, 0)
@@ -2926,6 +4265,19 @@ func pairContext() Context {
 
 		return t.Return(Car(s))
 	})
+	envp.PublicMethod("index-of", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1)
+		var s Cell = toPair(t.Self())
+
+		for i := int64(0); s != Null; i++ {
+			if Car(s).Equal(Car(args)) {
+				return t.Return(NewInteger(i))
+			}
+			s = Cdr(s)
+		}
+
+		return t.Return(NewInteger(-1))
+	})
 	envp.PublicMethod("keys", func(t *Task, args Cell) bool {
 		t.Validate(args, 0, 0)
 		var s Cell = toPair(t.Self())
@@ -2944,6 +4296,22 @@ func pairContext() Context {
 		t.Validate(args, 0, 0)
 		return t.Return(NewInteger(Length(t.Self())))
 	})
+	envp.PublicMethod("partition", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1)
+		var s Cell = toPair(t.Self())
+		l, r := Null, Null
+
+		for ; s != Null; s = Cdr(s) {
+			if invoke(t, Car(args), Car(s)).Bool() {
+				l = Cons(Car(s), l)
+			} else {
+				r = Cons(Car(s), r)
+			}
+		}
+
+		return t.Return(List(Reverse(l), Reverse(r)))
+	})
+	envp.PublicMethod("reduce", reduce)
 	envp.PublicMethod("reverse", func(t *Task, args Cell) bool {
 		t.Validate(args, 0, 0)
 		return t.Return(Reverse(t.Self()))
@@ -2989,12 +4357,44 @@ func pairContext() Context {
 
 		return t.Return(Slice(s, i, j))
 	})
+	envp.PublicMethod("sort", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 1)
+		var s Cell = toPair(t.Self())
+		l := []Cell{}
+
+		for ; s != Null; s = Cdr(s) {
+			l = append(l, Car(s))
+		}
+
+		less := func(i, j int) bool {
+			return ordered(l[i], l[j])
+		}
+		if args != Null {
+			less = func(i, j int) bool {
+				return invoke(t, Car(args), l[i], l[j]).Bool()
+			}
+		}
+		sort.SliceStable(l, less)
+
+		return t.Return(List(l...))
+	})
 	envp.PublicMethod("tail", func(t *Task, args Cell) bool {
 		t.Validate(args, 0, 0)
 		s := toPair(t.Self())
 
 		return t.Return(Cdr(s))
 	})
+	envp.PublicMethod("take-while", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1)
+		var s Cell = toPair(t.Self())
+		l := Null
+
+		for ; s != Null && invoke(t, Car(args), Car(s)).Bool(); s = Cdr(s) {
+			l = Cons(Car(s), l)
+		}
+
+		return t.Return(Reverse(l))
+	})
 	envp.DefineMethod("to-string", func(t *Task, args Cell) bool {
 		t.Validate(args, 0, 0)
 		var s Cell
@@ -3006,15 +4406,347 @@ func pairContext() Context {
 
 		return t.Return(NewString(v))
 	})
+	envp.PublicMethod("to-set", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+		return t.Return(list2set(toPair(t.Self())))
+	})
+	envp.PublicMethod("to-vector", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+		return t.Return(list2vector(toPair(t.Self())))
+	})
+	envp.PublicMethod("uniq", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+		var s Cell = toPair(t.Self())
+		l := Null
+
+	next:
+		for ; s != Null; s = Cdr(s) {
+			for u := l; u != Null; u = Cdr(u) {
+				if Car(u).Equal(Car(s)) {
+					continue next
+				}
+			}
+			l = Cons(Car(s), l)
+		}
+
+		return t.Return(Reverse(l))
+	})
+	envp.PublicMethod("zip", func(t *Task, args Cell) bool {
+		validators := make([]Validator, Length(args))
+		for i := range validators {
+			validators[i] = IsPair
+		}
+		t.Validate(args, 0, -1, validators...)
+
+		lists := []Cell{toPair(t.Self())}
+		for ; args != Null; args = Cdr(args) {
+			lists = append(lists, Car(args))
+		}
+
+		l := Null
+		for {
+			n := Null
+			for i, s := range lists {
+				if s == Null {
+					return t.Return(Reverse(l))
+				}
+				n = Cons(Car(s), n)
+				lists[i] = Cdr(s)
+			}
+			l = Cons(Reverse(n), l)
+		}
+	})
 
 	return envp
 }
 
+func parallel(t *Task, p, items, body Cell, n int64) Cell {
+	if v, ok := items.(*Vector); ok {
+		items = v.List()
+	}
+
+	c := toContext(t.Lexical)
+	failed := make(chan *failure, 1)
+	finished := make(chan bool)
+
+	tasks := []*Task{}
+	running := int64(0)
+
+	var f *failure
+	for f == nil && (items != Null || running > 0) {
+		if items != Null && running < n {
+			s := NewScope(c, nil)
+			destructure(p, Car(items), s.Define)
+
+			child := isolate(t, body, s, failed)
+			tasks = append(tasks, child)
+
+			go func() {
+				child.Launch()
+				finished <- true
+			}()
+
+			items = Cdr(items)
+			running++
+
+			continue
+		}
+
+		select {
+		case <-finished:
+			running--
+		case f = <-failed:
+		case <-t.stopped:
+			f = &failure{}
+		}
+	}
+
+	if f == nil {
+		select {
+		case f = <-failed:
+		default:
+		}
+	}
+
+	if f != nil {
+		for _, child := range tasks {
+			child.Stop()
+		}
+	}
+
+	for ; running > 0; running-- {
+		<-finished
+	}
+
+	t.childrenl.Lock()
+	results := make([]Cell, len(tasks))
+	for i, child := range tasks {
+		delete(t.children, child)
+		results[i] = Car(child.Dump)
+	}
+	t.childrenl.Unlock()
+
+	if f != nil && f.escape != nil {
+		panic(f.escape)
+	}
+
+	if f != nil && f.exception != nil {
+		panic(f)
+	}
+
+	return List(results...)
+}
+
+func parameter(p Cell) Cell {
+	if IsCons(p) {
+		return Car(p)
+	}
+
+	return p
+}
+
+func pattern(p Cell) {
+	name := func(c Cell) bool {
+		return IsSymbol(c) && !IsNumber(c)
+	}
+
+	for ; p != Null; p = Cdr(p) {
+		e := Car(p)
+		if name(e) {
+			continue
+		}
+
+		rest := IsCons(e) && e != Null && Cdr(e) == Null
+		if rest && name(Car(e)) && Cdr(p) == Null {
+			continue
+		}
+
+		s := e.String()
+		if IsCons(e) {
+			s = "(" + s + ")"
+		}
+
+		panic(common.ErrSyntax + "unexpected pattern element " + s)
+	}
+}
+
+func publics(c Context) *Map {
+	public := c.Faces().prev.Prefixed("")
+
+	keys := make([]string, 0, len(public))
+	for k := range public {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	m := NewMap()
+	for _, k := range keys {
+		m.Set(NewSymbol(k), public[k])
+	}
+
+	return m
+}
+
+func regional(r rune) bool {
+	return 0x1f1e6 <= r && r <= 0x1f1ff
+}
+
+func reduce(t *Task, args Cell) bool {
+	t.Validate(args, 1, 2)
+	var s Cell = toPair(t.Self())
+
+	acc := Car(s)
+	if Cdr(args) == Null {
+		s = Cdr(s)
+	} else {
+		acc = Cadr(args)
+	}
+
+	for ; s != Null; s = Cdr(s) {
+		acc = invoke(t, Car(args), acc, Car(s))
+	}
+
+	return t.Return(acc)
+}
+
+func resume(t *Task) (done bool) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		e, ok := r.(*escape)
+		if !ok || !e.reaches(t.base) {
+			panic(r)
+		}
+
+		t.Continuation = *e.c
+		t.Dump = Cons(e.v, t.Dump)
+	}()
+
+	if t.step(nil) != 0 {
+		panic(&escape{nil, Car(t.Dump)})
+	}
+
+	return true
+}
+
 func rpipe(c Cell) *os.File {
 	return c.(*Pipe).ReadFd()
 
 }
 
+func runeIndex(s string, i int) int64 {
+	if i < 0 {
+		return -1
+	}
+
+	return int64(utf8.RuneCountInString(s[:i]))
+}
+
+func segment(s string, args Cell) ([]string, Cell) {
+	unit := "-r"
+	if args != Null {
+		switch Raw(Car(args)) {
+		case "-b", "-g", "-r":
+			unit = Raw(Car(args))
+			args = Cdr(args)
+		}
+	}
+
+	switch unit {
+	case "-b":
+		l := make([]string, len(s))
+		for i := range l {
+			l[i] = s[i : i+1]
+		}
+		return l, args
+
+	case "-g":
+		return graphemes(s), args
+	}
+
+	l := make([]string, 0, len(s))
+	for _, r := range s {
+		l = append(l, string(r))
+	}
+
+	return l, args
+}
+
+func setContext() Context {
+	if envt != nil {
+		return envt
+	}
+
+	envt = NewScope(namespace, nil)
+	envt.PublicMethod("add", func(t *Task, args Cell) bool {
+		s := toSet(t.Self())
+
+		for ; args != Null; args = Cdr(args) {
+			s.Add(Car(args))
+		}
+
+		return t.Return(s)
+	})
+	envt.PublicMethod("difference", func(t *Task, args Cell) bool {
+		s := toSet(t.Self())
+
+		for ; args != Null; args = Cdr(args) {
+			s = s.Difference(toSet(Car(args)))
+		}
+
+		return t.Return(s)
+	})
+	envt.PublicMethod("has", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1)
+		return t.Return(NewBoolean(toSet(t.Self()).Has(Car(args))))
+	})
+	envt.PublicMethod("intersection", func(t *Task, args Cell) bool {
+		s := toSet(t.Self())
+
+		for ; args != Null; args = Cdr(args) {
+			s = s.Intersection(toSet(Car(args)))
+		}
+
+		return t.Return(s)
+	})
+	envt.PublicMethod("length", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+		return t.Return(NewInteger(toSet(t.Self()).Length()))
+	})
+	envt.PublicMethod("remove", func(t *Task, args Cell) bool {
+		s := toSet(t.Self())
+
+		ok := true
+		for ; args != Null; args = Cdr(args) {
+			ok = s.Remove(Car(args)) && ok
+		}
+
+		return t.Return(NewBoolean(ok))
+	})
+	envt.PublicMethod("subset", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1)
+		return t.Return(NewBoolean(toSet(t.Self()).Subset(toSet(Car(args)))))
+	})
+	envt.PublicMethod("to-list", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+		return t.Return(toSet(t.Self()).List())
+	})
+	envt.PublicMethod("union", func(t *Task, args Cell) bool {
+		s := toSet(t.Self())
+
+		for ; args != Null; args = Cdr(args) {
+			s = s.Union(toSet(Car(args)))
+		}
+
+		return t.Return(s)
+	})
+
+	return envt
+}
+
 func setForegroundTask(t *Task) {
 	if t.Job.Group != 0 {
 		system.SetForegroundGroup(t.Job.Group)
@@ -3025,6 +4757,18 @@ func setForegroundTask(t *Task) {
 	task0.Continue()
 }
 
+func sortRank(c Cell) int {
+	if _, ok := c.(*String); ok {
+		return 1
+	}
+
+	if IsNumber(c) {
+		return 0
+	}
+
+	return 2
+}
+
 func status(c Cell) *Status {
 	a, ok := c.(Atom)
 	if !ok {
@@ -3039,6 +4783,53 @@ func stringContext() Context {
 	}
 
 	envs = NewScope(namespace, nil)
+	envs.PublicMethod("bytes", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+		s := Raw(toString(t.Self()))
+
+		l := Null
+		for i := len(s) - 1; i >= 0; i-- {
+			l = Cons(NewInteger(int64(s[i])), l)
+		}
+
+		return t.Return(l)
+	})
+	envs.PublicMethod("fields", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+		l := strings.Fields(Raw(toString(t.Self())))
+
+		return t.Return(strings2list(l))
+	})
+	envs.PublicMethod("find", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1, IsText)
+		s := Raw(toString(t.Self()))
+		re := compile(Raw(Car(args)))
+
+		m := re.FindStringSubmatch(s)
+		if m == nil {
+			return t.Return(Null)
+		}
+
+		return t.Return(captures(re, m))
+	})
+	envs.PublicMethod("find-all", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1, IsText)
+		s := Raw(toString(t.Self()))
+		re := compile(Raw(Car(args)))
+
+		all := re.FindAllStringSubmatch(s, -1)
+
+		l := Null
+		for i := len(all) - 1; i >= 0; i-- {
+			l = Cons(captures(re, all[i]), l)
+		}
+
+		return t.Return(l)
+	})
+	envs.PublicMethod("graphemes", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+		return t.Return(strings2list(graphemes(Raw(toString(t.Self())))))
+	})
 	envs.PublicMethod("join", func(t *Task, args Cell) bool {
 		sep := toString(t.Self())
 		arr := make([]string, Length(args))
@@ -3057,14 +4848,91 @@ func stringContext() Context {
 		return t.Return(Null)
 	})
 	envs.PublicMethod("length", func(t *Task, args Cell) bool {
-		t.Validate(args, 0, 0)
+		t.Validate(args, 0, 1, IsText)
+		l, args := segment(Raw(toString(t.Self())), args)
+		if args != Null {
+			panic("unknown option " + Raw(Car(args)))
+		}
+
+		return t.Return(NewInteger(int64(len(l))))
+	})
+	envs.PublicMethod("pad-left", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 2, IsNumber, IsText)
+		s := Raw(toString(t.Self()))
+
+		return t.Return(NewString(padding(s, args) + s))
+	})
+	envs.PublicMethod("pad-right", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 2, IsNumber, IsText)
+		s := Raw(toString(t.Self()))
+
+		return t.Return(NewString(s + padding(s, args)))
+	})
+	envs.PublicMethod("repeat", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1, IsNumber)
+		s := Raw(toString(t.Self()))
+		n := Car(args).(Atom).Int()
+
+		if n < 0 {
+			panic("repeat count must not be negative")
+		}
+
+		if len(s) > 0 && n > int64(maxRepeat/len(s)) {
+			panic("repeat count " + strconv.FormatInt(n, 10) + " is too large")
+		}
+
+		return t.Return(NewString(strings.Repeat(s, int(n))))
+	})
+	envs.PublicMethod("replace", func(t *Task, args Cell) bool {
+		t.Validate(args, 2, 2, IsText, IsText)
 		s := Raw(toString(t.Self()))
 
-		return t.Return(NewInteger(int64(len(s))))
+		return t.Return(NewString(
+			strings.Replace(s, Raw(Car(args)), Raw(Cadr(args)), 1),
+		))
+	})
+	envs.PublicMethod("replace-all", func(t *Task, args Cell) bool {
+		t.Validate(args, 2, 2, IsText, IsText)
+		s := Raw(toString(t.Self()))
+
+		return t.Return(NewString(
+			strings.Replace(s, Raw(Car(args)), Raw(Cadr(args)), -1),
+		))
+	})
+	envs.PublicMethod("replace-all-regex", func(t *Task, args Cell) bool {
+		t.Validate(args, 2, 2, IsText, IsText)
+		s := Raw(toString(t.Self()))
+		re := compile(Raw(Car(args)))
+
+		return t.Return(NewString(re.ReplaceAllString(s, Raw(Cadr(args)))))
+	})
+	envs.PublicMethod("replace-regex", func(t *Task, args Cell) bool {
+		t.Validate(args, 2, 2, IsText, IsText)
+		s := Raw(toString(t.Self()))
+		re := compile(Raw(Car(args)))
+		r := Raw(Cadr(args))
+
+		loc := re.FindStringSubmatchIndex(s)
+		if loc == nil {
+			return t.Return(NewString(s))
+		}
+
+		b := []byte(s[:loc[0]])
+		b = re.ExpandString(b, r, s, loc)
+		b = append(b, s[loc[1]:]...)
+
+		return t.Return(NewString(string(b)))
+	})
+	envs.PublicMethod("runes", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+		l, _ := segment(Raw(toString(t.Self())), Null)
+
+		return t.Return(strings2list(l))
 	})
 	envs.PublicMethod("slice", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 3)
+		s, args := segment(Raw(toString(t.Self())), args)
 		t.Validate(args, 1, 2, IsNumber, IsNumber)
-		s := []rune(Raw(toString(t.Self())))
 
 		start := int(Car(args).(Atom).Int())
 		end := len(s)
@@ -3073,7 +4941,13 @@ func stringContext() Context {
 			end = int(Cadr(args).(Atom).Int())
 		}
 
-		return t.Return(NewString(string(s[start:end])))
+		if start < 0 || start > len(s) {
+			panic("slice starts outside of string")
+		} else if end < start || end > len(s) {
+			panic("slice ends outside of string")
+		}
+
+		return t.Return(NewString(strings.Join(s[start:end], "")))
 	})
 	envs.PublicMethod("split", func(t *Task, args Cell) bool {
 		t.Validate(args, 1, 1, IsText)
@@ -3090,12 +4964,29 @@ func stringContext() Context {
 
 		return t.Return(r)
 	})
+	envs.PublicMethod("split-regex", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 1, IsText)
+		r := Null
+
+		sep := toString(t.Self())
+		str := Car(args)
+
+		l := compile(Raw(sep)).Split(Raw(str), -1)
+
+		for i := len(l) - 1; i >= 0; i-- {
+			r = Cons(NewString(l[i]), r)
+		}
+
+		return t.Return(r)
+	})
 	envs.PublicMethod("sprintf", func(t *Task, args Cell) bool {
 		f := Raw(toString(t.Self()))
 
 		argv := []interface{}{}
 		for l := args; l != Null; l = Cdr(l) {
 			switch t := Car(l).(type) {
+			case *BigInteger:
+				argv = append(argv, t.Big())
 			case *Boolean:
 				argv = append(argv, *t)
 			case *Integer:
@@ -3129,6 +5020,49 @@ func stringContext() Context {
 	return envs
 }
 
+func strings2list(l []string) Cell {
+	r := Null
+	for i := len(l) - 1; i >= 0; i-- {
+		r = Cons(NewString(l[i]), r)
+	}
+
+	return r
+}
+
+func taskContext() Context {
+	if envk != nil {
+		return envk
+	}
+
+	envk = NewScope(namespace, nil)
+	envk.PublicMethod("cancel", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+		toTask(t.Self()).Stop()
+		return t.Return(True)
+	})
+	envk.PublicMethod("done?", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+		select {
+		case <-toTask(t.Self()).Done:
+			return t.Return(True)
+		default:
+			return t.Return(False)
+		}
+	})
+	envk.PublicMethod("status", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+		child := toTask(t.Self())
+		select {
+		case <-child.Done:
+			return t.Return(Car(child.Dump))
+		default:
+			return t.Return(Null)
+		}
+	})
+
+	return envk
+}
+
 /* Convert Context into a Conduit. */
 func toConduit(c Cell) Conduit {
 	conduit := asConduit(c)
@@ -3149,6 +5083,15 @@ func toContext(c Cell) Context {
 	return context
 }
 
+/* Convert Cell into a Map. */
+func toMap(c Cell) *Map {
+	if m, ok := c.(*Map); ok {
+		return m
+	}
+
+	panic("not a map")
+}
+
 /* Convert Cell into a Pair. */
 func toPair(c Cell) *Pair {
 	if p, ok := c.(*Pair); ok {
@@ -3158,6 +5101,15 @@ func toPair(c Cell) *Pair {
 	panic("not a string")
 }
 
+/* Convert Cell into a Set. */
+func toSet(c Cell) *Set {
+	if s, ok := c.(*Set); ok {
+		return s
+	}
+
+	panic("not a set")
+}
+
 /* Convert Cell into a String. */
 func toString(c Cell) *String {
 	if s, ok := c.(*String); ok {
@@ -3167,6 +5119,98 @@ func toString(c Cell) *String {
 	panic("not a string")
 }
 
+/* Convert Cell into a Task. */
+func toTask(c Cell) *Task {
+	if t, ok := c.(*Task); ok {
+		return t
+	}
+
+	panic("not a task")
+}
+
+/* Convert Cell into a Vector. */
+func toVector(c Cell) *Vector {
+	if v, ok := c.(*Vector); ok {
+		return v
+	}
+
+	panic("not a vector")
+}
+
+func vectorContext() Context {
+	if envv != nil {
+		return envv
+	}
+
+	envv = NewScope(namespace, nil)
+	envv.PublicMethod("get", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 2, IsNumber)
+		v := toVector(t.Self())
+
+		var dflt Cell = nil
+		if Cdr(args) != Null {
+			dflt = Cadr(args)
+		}
+
+		return t.Return(v.Get(Car(args).(Atom).Int(), dflt))
+	})
+	envv.PublicMethod("keys", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+		l := Null
+
+		for i := toVector(t.Self()).Length() - 1; i >= 0; i-- {
+			l = Cons(NewInteger(i), l)
+		}
+
+		return t.Return(l)
+	})
+	envv.PublicMethod("length", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+		return t.Return(NewInteger(toVector(t.Self()).Length()))
+	})
+	envv.PublicMethod("pop", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+		return t.Return(toVector(t.Self()).Pop())
+	})
+	envv.PublicMethod("push", func(t *Task, args Cell) bool {
+		v := toVector(t.Self())
+
+		for ; args != Null; args = Cdr(args) {
+			v.Push(Car(args))
+		}
+
+		return t.Return(v)
+	})
+	envv.PublicMethod("set", func(t *Task, args Cell) bool {
+		t.Validate(args, 2, 2, IsNumber)
+		v := Cadr(args)
+
+		toVector(t.Self()).Set(Car(args).(Atom).Int(), v)
+
+		return t.Return(v)
+	})
+	envv.PublicMethod("slice", func(t *Task, args Cell) bool {
+		t.Validate(args, 1, 2, IsNumber, IsNumber)
+		v := toVector(t.Self())
+		i := Car(args).(Atom).Int()
+
+		j := int64(0)
+
+		args = Cdr(args)
+		if args != Null {
+			j = Car(args).(Atom).Int()
+		}
+
+		return t.Return(v.Slice(i, j))
+	})
+	envv.PublicMethod("to-list", func(t *Task, args Cell) bool {
+		t.Validate(args, 0, 0)
+		return t.Return(toVector(t.Self()).List())
+	})
+
+	return envv
+}
+
 func wpipe(c Cell) *os.File {
 	return c.(*Pipe).WriteFd()
 }
@@ -0,0 +1,106 @@
+// Released under an MIT license. See LICENSE.
+
+package task
+
+import (
+	. "github.com/michaelmacinnis/oh/pkg/cell"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	trapped = make(chan os.Signal, 16)
+	traps   = map[string]Cell{}
+	trapsl  = &sync.RWMutex{}
+)
+
+func signalName(s string) string {
+	if n, err := strconv.Atoi(s); err == nil {
+		return signalNumber(n)
+	}
+
+	s = strings.ToUpper(s)
+	if s != "EXIT" && !strings.HasPrefix(s, "SIG") {
+		s = "SIG" + s
+	}
+
+	if _, ok := signals[s]; !ok && s != "EXIT" {
+		panic("unknown signal " + s)
+	}
+
+	return s
+}
+
+func trap(name string, handler Cell) {
+	trapsl.Lock()
+	defer trapsl.Unlock()
+
+	if handler == nil {
+		delete(traps, name)
+	} else if name == "SIGKILL" || name == "SIGSTOP" {
+		panic(name + " cannot be trapped")
+	} else {
+		traps[name] = handler
+	}
+
+	signal.Stop(trapped)
+	for k := range traps {
+		if s, ok := signals[k]; ok {
+			signal.Notify(trapped, s)
+		}
+	}
+}
+
+func trapHandler(name string) Cell {
+	trapsl.RLock()
+	defer trapsl.RUnlock()
+
+	return traps[name]
+}
+
+func trapListing() *Map {
+	trapsl.RLock()
+	defer trapsl.RUnlock()
+
+	keys := make([]string, 0, len(traps))
+	for k := range traps {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	m := NewMap()
+	for _, k := range keys {
+		m.Set(NewSymbol(k), traps[k])
+	}
+
+	return m
+}
+
+func trapSignal(t *Task) {
+	select {
+	case s := <-trapped:
+		for k, v := range signals {
+			if v != s {
+				continue
+			}
+
+			h := trapHandler(k)
+			if h == nil {
+				return
+			}
+
+			t.NewStates(SaveCode|SaveDump|SaveFrame|SaveLexical,
+				psEvalCommand)
+
+			t.Code = List(h, NewSymbol(k))
+
+			return
+		}
+
+	default:
+	}
+}
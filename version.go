@@ -1,6 +1,6 @@
-// +build !go1.5
+//go:build !go1.24
 
 package main
 
-"Oh requires Go 1.5 or greater to build."
+"Oh requires Go 1.24 or greater to build."
 